@@ -0,0 +1,316 @@
+package physfs
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"runtime/cgo"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+// An Archiver adds support for an archive format to PhysicsFS. See
+// RegisterArchiver.
+type Archiver interface {
+	// Info returns information about the archive format. The extension is
+	// used to pick which archiver to try first when mounting a file, and is
+	// also how the archiver is identified by DeregisterArchiver.
+	Info() ArchiveInfo
+
+	// Open opens the archive named name, which can be read from r and is size
+	// bytes long. r is only valid until the returned Archive is closed. If the
+	// data is not in the archiver's format, Open should return an error that
+	// wraps ErrNotArchive so that PhysicsFS goes on to try other archivers.
+	// Any other error stops the mount.
+	Open(r io.ReaderAt, size int64, name string) (Archive, error)
+}
+
+// An Archive is a single archive opened by an Archiver. Paths passed to its
+// methods are relative to the root of the archive, use "/" as a separator and
+// have no leading or trailing separators. The root itself is "".
+//
+// Archives are read-only. PhysicsFS may call the methods of an Archive from
+// several goroutines at once.
+type Archive interface {
+	// Enumerate returns the names of the entries in the directory dir.
+	Enumerate(dir string) ([]string, error)
+
+	// OpenRead returns a reader for the contents of the named file along
+	// with its size. r must support concurrent calls to ReadAt.
+	OpenRead(name string) (r io.ReaderAt, size int64, err error)
+
+	// Stat returns metadata about the named entry. If it doesn't exist, Stat
+	// should return an error that wraps fs.ErrNotExist.
+	Stat(name string) (StatInfo, error)
+
+	// Close releases any resources associated with the archive.
+	Close() error
+}
+
+// Returned by Archiver.Open to signal that the data is not in a format that
+// the Archiver understands.
+var ErrNotArchive = errors.New("Not an archive of this type.")
+
+var (
+	archiversLock sync.Mutex
+
+	// PhysicsFS doesn't tell openArchive which archiver it was called for, so
+	// each registered Archiver gets its own C function, identified by its
+	// index in this array.
+	archivers [C.ARCHIVER_SLOTS]Archiver
+)
+
+// openArchive is the Go side of an archive opened by a registered Archiver.
+type openArchive struct {
+	archive Archive
+	cio     *C.PHYSFS_Io
+}
+
+func getOpenArchive(h C.uintptr_t) *openArchive {
+	return cgo.Handle(h).Value().(*openArchive)
+}
+
+//export archiverOpenArchive
+func archiverOpenArchive(slot C.int, cio *C.PHYSFS_Io, name *C.char, claimed *C.int) (h C.uintptr_t) {
+	defer recoverAppCallback(func() { *claimed, h = 1, 0 })
+
+	archiversLock.Lock()
+	archiver := archivers[slot]
+	archiversLock.Unlock()
+
+	if (archiver == nil) || (cio == nil) {
+		C.PHYSFS_setErrorCode(C.PHYSFS_ERR_UNSUPPORTED)
+		return 0
+	}
+
+	r := &ioReaderAt{cio: cio}
+	archive, err := archiver.Open(r, r.Size(), C.GoString(name))
+	if err != nil {
+		if !errors.Is(err, ErrNotArchive) {
+			*claimed = 1
+		}
+		setErrorCode(err)
+		return 0
+	}
+	*claimed = 1
+
+	return C.uintptr_t(cgo.NewHandle(&openArchive{
+		archive: archive,
+		cio:     cio,
+	}))
+}
+
+//export archiverEnumerate
+func archiverEnumerate(h C.uintptr_t, dir *C.char, cb C.PHYSFS_EnumerateCallback, origdir *C.char, data unsafe.Pointer) (r C.PHYSFS_EnumerateCallbackResult) {
+	defer recoverAppCallback(func() { r = C.PHYSFS_ENUM_ERROR })
+
+	names, err := getOpenArchive(h).archive.Enumerate(C.GoString(dir))
+	if err != nil {
+		setErrorCode(err)
+		return C.PHYSFS_ENUM_ERROR
+	}
+
+	for _, name := range names {
+		cname := C.CString(name)
+		res := C.callEnumerateCallback(cb, data, origdir, cname)
+		C.free(unsafe.Pointer(cname))
+
+		if res != C.PHYSFS_ENUM_OK {
+			return res
+		}
+	}
+
+	return C.PHYSFS_ENUM_OK
+}
+
+//export archiverOpenRead
+func archiverOpenRead(h C.uintptr_t, name *C.char) (cio *C.PHYSFS_Io) {
+	defer recoverAppCallback(func() { cio = nil })
+
+	r, size, err := getOpenArchive(h).archive.OpenRead(C.GoString(name))
+	if err != nil {
+		setErrorCode(err)
+		return nil
+	}
+
+	return newReaderAtIo(&readerAtIo{
+		r:    r,
+		size: size,
+	})
+}
+
+//export archiverStat
+func archiverStat(h C.uintptr_t, name *C.char, stat *C.PHYSFS_Stat) (r C.int) {
+	defer recoverAppCallback(func() { r = 0 })
+
+	info, err := getOpenArchive(h).archive.Stat(C.GoString(name))
+	if err != nil {
+		setErrorCode(err)
+		return 0
+	}
+
+	// Archives implemented in Go are always read-only.
+	info.ReadOnly = true
+	info.toC(stat)
+
+	return 1
+}
+
+//export archiverClose
+func archiverClose(h C.uintptr_t) {
+	oa := getOpenArchive(h)
+	cgo.Handle(h).Delete()
+	defer C.ioDestroy(oa.cio)
+	defer recoverAppCallback(func() {})
+
+	oa.archive.Close()
+}
+
+// ioReaderAt exposes a PHYSFS_Io as an io.ReaderAt.
+type ioReaderAt struct {
+	lock sync.Mutex
+	cio  *C.PHYSFS_Io
+}
+
+func (r *ioReaderAt) Size() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return int64(C.ioLength(r.cio))
+}
+
+func (r *ioReaderAt) ReadAt(buf []byte, off int64) (n int, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	size := int64(C.ioLength(r.cio))
+	for n < len(buf) {
+		if off+int64(n) >= size {
+			return n, io.EOF
+		}
+
+		read := int64(C.ioReadAt(r.cio, unsafe.Pointer(&buf[n]), C.PHYSFS_uint64(len(buf)-n), C.PHYSFS_uint64(off+int64(n))))
+		if read < 0 {
+			return n, lastError("read", "")
+		}
+		if read == 0 {
+			return n, io.EOF
+		}
+
+		n += int(read)
+	}
+
+	return n, nil
+}
+
+// Registers a Go implementation of an archive format with PhysicsFS. Once
+// registered, Mount, SetSaneConfig and friends will use a to open archives
+// with its extension, and will fall back to trying it for files that no other
+// archiver claims. The format is also listed by SupportedArchiveTypes().
+// PhysicsFS must be initialized, and Deinit() forgets all registered
+// archivers. Only a limited number of archivers can be registered at once, and
+// this fails with ErrOutOfMemory once they are all in use. Returns an error, if
+// any.
+func RegisterArchiver(a Archiver) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// The archiver has to be in place before registering, as PhysicsFS may
+	// start using it right away. archiversLock isn't held while calling into
+	// PhysicsFS, as archiverOpenArchive takes it while PhysicsFS holds its own
+	// lock.
+	info := a.Info()
+	slot := reserveArchiverSlot(a)
+	if slot < 0 {
+		return &PathError{Op: "registerarchiver", Path: info.Extension, Code: ErrOutOfMemory}
+	}
+
+	symlinks := 0
+	if info.SupportsSymlinks {
+		symlinks = 1
+	}
+
+	cext := C.CString(info.Extension)
+	defer C.free(unsafe.Pointer(cext))
+	cdesc := C.CString(info.Description)
+	defer C.free(unsafe.Pointer(cdesc))
+	cauthor := C.CString(info.Author)
+	defer C.free(unsafe.Pointer(cauthor))
+	curl := C.CString(info.URL)
+	defer C.free(unsafe.Pointer(curl))
+
+	if int(C.registerArchiver(C.int(slot), cext, cdesc, cauthor, curl, C.int(symlinks))) != 0 {
+		return nil
+	}
+
+	err := lastError("registerarchiver", info.Extension)
+	archiversLock.Lock()
+	archivers[slot] = nil
+	archiversLock.Unlock()
+
+	return err
+}
+
+// Puts a in the first free slot, and returns the slot, or -1 if there isn't
+// one.
+func reserveArchiverSlot(a Archiver) int {
+	archiversLock.Lock()
+	defer archiversLock.Unlock()
+
+	for i := range archivers {
+		if archivers[i] == nil {
+			archivers[i] = a
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Deregisters the archiver for the archive type with the extension ext, which
+// may be one registered with RegisterArchiver or one built into PhysicsFS. This
+// fails if any archives of that type are still in the search path. Returns an
+// error, if any.
+func DeregisterArchiver(ext string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Not holding archiversLock, for the same reason as RegisterArchiver.
+	cext := C.CString(ext)
+	defer C.free(unsafe.Pointer(cext))
+	if int(C.PHYSFS_deregisterArchiver(cext)) == 0 {
+		return lastError("deregisterarchiver", ext)
+	}
+
+	archiversLock.Lock()
+	defer archiversLock.Unlock()
+
+	for i := range archivers {
+		if (archivers[i] != nil) && strings.EqualFold(archivers[i].Info().Extension, ext) {
+			archivers[i] = nil
+		}
+	}
+
+	return nil
+}
+
+// Forgets all of the registered archivers. PhysicsFS does the same on its side
+// when it is deinitialized.
+func resetArchivers() {
+	archiversLock.Lock()
+	defer archiversLock.Unlock()
+
+	for i := range archivers {
+		archivers[i] = nil
+	}
+}
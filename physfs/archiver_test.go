@@ -0,0 +1,157 @@
+package physfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+// tarArchiver is a simple Archiver for uncompressed tar files.
+type tarArchiver struct{}
+
+func (tarArchiver) Info() ArchiveInfo {
+	return ArchiveInfo{
+		Extension:   "GOTAR",
+		Description: "Tar archives, implemented in Go",
+	}
+}
+
+func (tarArchiver) Open(r io.ReaderAt, size int64, name string) (Archive, error) {
+	archive := tarArchive{"": nil}
+
+	tr := tar.NewReader(io.NewSectionReader(r, 0, size))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrNotArchive)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		archive[strings.Trim(hdr.Name, "/")] = data
+	}
+
+	return archive, nil
+}
+
+// tarArchive maps file names to their contents. The root directory maps to nil.
+// Other directories aren't supported.
+type tarArchive map[string][]byte
+
+func (a tarArchive) Enumerate(dir string) ([]string, error) {
+	var names []string
+	for name := range a {
+		parent := path.Dir(name)
+		if parent == "." {
+			parent = ""
+		}
+
+		if (name != "") && (parent == dir) {
+			names = append(names, path.Base(name))
+		}
+	}
+
+	return names, nil
+}
+
+func (a tarArchive) OpenRead(name string) (io.ReaderAt, int64, error) {
+	data, ok := a[name]
+	if !ok || (data == nil) {
+		return nil, 0, fs.ErrNotExist
+	}
+
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+func (a tarArchive) Stat(name string) (StatInfo, error) {
+	data, ok := a[name]
+	if !ok {
+		return StatInfo{}, fs.ErrNotExist
+	}
+	if data == nil {
+		return StatInfo{Type: FileTypeDirectory}, nil
+	}
+
+	return StatInfo{Size: int64(len(data))}, nil
+}
+
+func (a tarArchive) Close() error {
+	return nil
+}
+
+func TestRegisterArchiver(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := RegisterArchiver(tarArchiver{})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	found := false
+	for _, ai := range SupportedArchiveTypes() {
+		if ai.Extension == "GOTAR" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("GOTAR missing from SupportedArchiveTypes()\n")
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "file1", Mode: 0644, Size: 5})
+	tw.Write([]byte("hello"))
+	tw.Close()
+
+	err = MountMemory(buf.Bytes(), "test.gotar", "tar", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	files, err := EnumerateFiles("tar")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (len(files) != 1) || (files[0] != "file1") {
+		t.Fatalf("Unexpected contents: %v\n", files)
+	}
+
+	file, err := Open("tar/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("Unexpected data: %q\n", data)
+	}
+
+	err = RemoveFromSearchPath("test.gotar")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	err = DeregisterArchiver("GOTAR")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+}
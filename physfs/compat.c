@@ -0,0 +1,284 @@
+#include <string.h>
+
+#include "compat.h"
+
+#if !PHYSFS_AT_LEAST(3, 0)
+
+static __thread PHYSFS_ErrorCode lastErrorCode = PHYSFS_ERR_OK;
+
+static const struct
+{
+	const char *message;
+	PHYSFS_ErrorCode code;
+} legacyErrors[] = {
+	{"Already initialized", PHYSFS_ERR_IS_INITIALIZED},
+	{"Not initialized", PHYSFS_ERR_NOT_INITIALIZED},
+	{"Invalid argument", PHYSFS_ERR_INVALID_ARGUMENT},
+	{"Files still open", PHYSFS_ERR_FILES_STILL_OPEN},
+	{"Out of memory", PHYSFS_ERR_OUT_OF_MEMORY},
+	{"No such entry in search path", PHYSFS_ERR_NOT_MOUNTED},
+	{"Operation not supported", PHYSFS_ERR_UNSUPPORTED},
+	{"Archive type unsupported", PHYSFS_ERR_UNSUPPORTED},
+	{"Not implemented", PHYSFS_ERR_UNSUPPORTED},
+	{"Insecure filename", PHYSFS_ERR_BAD_FILENAME},
+	{"Bad filename", PHYSFS_ERR_BAD_FILENAME},
+	{"Symbolic links are disabled", PHYSFS_ERR_SYMLINK_FORBIDDEN},
+	{"Write directory is not set", PHYSFS_ERR_NO_WRITE_DIR},
+	{"File not found", PHYSFS_ERR_NOT_FOUND},
+	{"Path not found", PHYSFS_ERR_NOT_FOUND},
+	{"No such file or directory", PHYSFS_ERR_NOT_FOUND},
+	{"Past end of file", PHYSFS_ERR_PAST_EOF},
+	{"Seek out of range", PHYSFS_ERR_PAST_EOF},
+	{"Archive is read-only", PHYSFS_ERR_READ_ONLY},
+	{"I/O error", PHYSFS_ERR_IO},
+	{"Infinite symbolic link loop", PHYSFS_ERR_SYMLINK_LOOP},
+	{"Corrupted archive", PHYSFS_ERR_CORRUPT},
+	{"Operating system reported error", PHYSFS_ERR_OS_ERROR},
+	{"File already exists", PHYSFS_ERR_DUPLICATE},
+	{"Not a file", PHYSFS_ERR_NOT_A_FILE},
+	{"Permission denied", PHYSFS_ERR_PERMISSION},
+	{"argv0 is NULL", PHYSFS_ERR_ARGV0_IS_NULL},
+};
+
+static const char *errorMessages[] = {
+	"no error",
+	"unknown error",
+	"out of memory",
+	"not initialized",
+	"already initialized",
+	"argv[0] is NULL",
+	"unsupported",
+	"past end of file",
+	"files still open",
+	"invalid argument",
+	"not mounted",
+	"not found",
+	"symlinks are forbidden",
+	"write directory is not set",
+	"file open for reading",
+	"file open for writing",
+	"not a file",
+	"read-only filesystem",
+	"corrupted",
+	"infinite symbolic link loop",
+	"i/o error",
+	"permission denied",
+	"no space available for writing",
+	"filename is illegal or insecure",
+	"tried to modify a file the OS needs",
+	"directory isn't empty",
+	"OS reported an error",
+	"duplicate resource",
+	"bad password",
+	"app callback reported error",
+};
+
+PHYSFS_ErrorCode PHYSFS_getLastErrorCode(void)
+{
+	PHYSFS_ErrorCode code = lastErrorCode;
+	const char *message = PHYSFS_getLastError();
+
+	if (code != PHYSFS_ERR_OK)
+	{
+		lastErrorCode = PHYSFS_ERR_OK;
+		return code;
+	}
+
+	if (message == NULL)
+		return PHYSFS_ERR_OK;
+
+	for (size_t i = 0; i < sizeof(legacyErrors) / sizeof(legacyErrors[0]); i++)
+	{
+		if (strcmp(message, legacyErrors[i].message) == 0)
+			return legacyErrors[i].code;
+	}
+
+	return PHYSFS_ERR_OTHER_ERROR;
+}
+
+const char *PHYSFS_getErrorByCode(PHYSFS_ErrorCode code)
+{
+	if ((code < 0) || (code >= sizeof(errorMessages) / sizeof(errorMessages[0])))
+		return NULL;
+
+	return errorMessages[code];
+}
+
+void PHYSFS_setErrorCode(PHYSFS_ErrorCode code)
+{
+	lastErrorCode = code;
+}
+
+typedef struct
+{
+	PHYSFS_EnumerateCallback cb;
+	void *data;
+	PHYSFS_EnumerateCallbackResult result;
+} legacyEnumerateData;
+
+static void legacyEnumerateCallback(void *d, const char *origdir, const char *fname)
+{
+	legacyEnumerateData *data = d;
+
+	// The old API has no way to stop early, so the rest of the entries are
+	// skipped instead.
+	if (data->result != PHYSFS_ENUM_OK)
+		return;
+
+	data->result = data->cb(data->data, origdir, fname);
+}
+
+int PHYSFS_enumerate(const char *dir, PHYSFS_EnumerateCallback cb, void *d)
+{
+	legacyEnumerateData data = {cb, d, PHYSFS_ENUM_OK};
+
+	PHYSFS_enumerateFilesCallback(dir, &legacyEnumerateCallback, &data);
+	if (data.result == PHYSFS_ENUM_ERROR)
+	{
+		PHYSFS_setErrorCode(PHYSFS_ERR_APP_CALLBACK);
+		return 0;
+	}
+
+	return 1;
+}
+
+int PHYSFS_deregisterArchiver(const char *ext)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+#endif
+
+#if !PHYSFS_AT_LEAST(2, 1)
+
+int PHYSFS_stat(const char *fname, PHYSFS_Stat *stat)
+{
+	const char *realDir;
+	const char *writeDir;
+	PHYSFS_File *f;
+
+	if (!PHYSFS_exists(fname))
+	{
+		PHYSFS_setErrorCode(PHYSFS_ERR_NOT_FOUND);
+		return 0;
+	}
+
+	stat->filesize = -1;
+	stat->modtime = PHYSFS_getLastModTime(fname);
+	stat->createtime = -1;
+	stat->accesstime = -1;
+
+	if (PHYSFS_isSymbolicLink(fname))
+		stat->filetype = PHYSFS_FILETYPE_SYMLINK;
+	else if (PHYSFS_isDirectory(fname))
+		stat->filetype = PHYSFS_FILETYPE_DIRECTORY;
+	else
+	{
+		stat->filetype = PHYSFS_FILETYPE_REGULAR;
+
+		f = PHYSFS_openRead(fname);
+		if (f != NULL)
+		{
+			stat->filesize = PHYSFS_fileLength(f);
+			PHYSFS_close(f);
+		}
+	}
+
+	// Only files in the write directory can be written to.
+	realDir = PHYSFS_getRealDir(fname);
+	writeDir = PHYSFS_getWriteDir();
+	stat->readonly = (realDir == NULL) || (writeDir == NULL) || (strcmp(realDir, writeDir) != 0);
+
+	return 1;
+}
+
+PHYSFS_sint64 PHYSFS_readBytes(PHYSFS_File *handle, void *buffer, PHYSFS_uint64 len)
+{
+	PHYSFS_sint64 total = 0;
+
+	while (len > 0)
+	{
+		PHYSFS_uint32 chunk = (len > 0xFFFFFFFF) ? 0xFFFFFFFF : (PHYSFS_uint32)len;
+		PHYSFS_sint64 n = PHYSFS_read(handle, (char *)buffer + total, 1, chunk);
+		if (n < 0)
+			return (total > 0) ? total : -1;
+
+		total += n;
+		len -= n;
+		if (n < chunk)
+			break;
+	}
+
+	return total;
+}
+
+PHYSFS_sint64 PHYSFS_writeBytes(PHYSFS_File *handle, const void *buffer, PHYSFS_uint64 len)
+{
+	PHYSFS_sint64 total = 0;
+
+	while (len > 0)
+	{
+		PHYSFS_uint32 chunk = (len > 0xFFFFFFFF) ? 0xFFFFFFFF : (PHYSFS_uint32)len;
+		PHYSFS_sint64 n = PHYSFS_write(handle, (const char *)buffer + total, 1, chunk);
+		if (n < 0)
+			return (total > 0) ? total : -1;
+
+		total += n;
+		len -= n;
+		if (n < chunk)
+			break;
+	}
+
+	return total;
+}
+
+int PHYSFS_unmount(const char *oldDir)
+{
+	return PHYSFS_removeFromSearchPath(oldDir);
+}
+
+const char *PHYSFS_getPrefDir(const char *org, const char *app)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return NULL;
+}
+
+int PHYSFS_mountIo(PHYSFS_Io *io, const char *newDir, const char *mountPoint, int appendToPath)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+int PHYSFS_mountMemory(const void *buf, PHYSFS_uint64 len, void (*del)(void *), const char *newDir, const char *mountPoint, int appendToPath)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+int PHYSFS_mountHandle(PHYSFS_File *file, const char *newDir, const char *mountPoint, int appendToPath)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+#endif
+
+#if !PHYSFS_AT_LEAST(3, 1)
+
+int PHYSFS_setRoot(const char *archive, const char *subdir)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+#endif
+
+int archiveSupportsSymlinks(const PHYSFS_ArchiveInfo *info)
+{
+#if PHYSFS_AT_LEAST(2, 1)
+	return info->supportsSymlinks;
+#else
+	return 0;
+#endif
+}
@@ -0,0 +1,123 @@
+#ifndef GO_PHYSFS_COMPAT_H
+#define GO_PHYSFS_COMPAT_H
+
+#include <physfs.h>
+
+// Whether the PhysicsFS headers being built against are at least version
+// major.minor.
+#define PHYSFS_AT_LEAST(major, minor) \
+	((PHYSFS_VER_MAJOR > (major)) || ((PHYSFS_VER_MAJOR == (major)) && (PHYSFS_VER_MINOR >= (minor))))
+
+// The bindings are written against the PhysicsFS 3.x API. When built against
+// older headers, the parts of it that the bindings use are declared here and
+// implemented in compat.c on top of the older API, or fail with
+// PHYSFS_ERR_UNSUPPORTED if that isn't possible.
+
+#if !PHYSFS_AT_LEAST(3, 0)
+
+typedef enum PHYSFS_ErrorCode
+{
+	PHYSFS_ERR_OK,
+	PHYSFS_ERR_OTHER_ERROR,
+	PHYSFS_ERR_OUT_OF_MEMORY,
+	PHYSFS_ERR_NOT_INITIALIZED,
+	PHYSFS_ERR_IS_INITIALIZED,
+	PHYSFS_ERR_ARGV0_IS_NULL,
+	PHYSFS_ERR_UNSUPPORTED,
+	PHYSFS_ERR_PAST_EOF,
+	PHYSFS_ERR_FILES_STILL_OPEN,
+	PHYSFS_ERR_INVALID_ARGUMENT,
+	PHYSFS_ERR_NOT_MOUNTED,
+	PHYSFS_ERR_NOT_FOUND,
+	PHYSFS_ERR_SYMLINK_FORBIDDEN,
+	PHYSFS_ERR_NO_WRITE_DIR,
+	PHYSFS_ERR_OPEN_FOR_READING,
+	PHYSFS_ERR_OPEN_FOR_WRITING,
+	PHYSFS_ERR_NOT_A_FILE,
+	PHYSFS_ERR_READ_ONLY,
+	PHYSFS_ERR_CORRUPT,
+	PHYSFS_ERR_SYMLINK_LOOP,
+	PHYSFS_ERR_IO,
+	PHYSFS_ERR_PERMISSION,
+	PHYSFS_ERR_NO_SPACE,
+	PHYSFS_ERR_BAD_FILENAME,
+	PHYSFS_ERR_BUSY,
+	PHYSFS_ERR_DIR_NOT_EMPTY,
+	PHYSFS_ERR_OS_ERROR,
+	PHYSFS_ERR_DUPLICATE,
+	PHYSFS_ERR_BAD_PASSWORD,
+	PHYSFS_ERR_APP_CALLBACK
+} PHYSFS_ErrorCode;
+
+PHYSFS_ErrorCode PHYSFS_getLastErrorCode(void);
+const char *PHYSFS_getErrorByCode(PHYSFS_ErrorCode);
+void PHYSFS_setErrorCode(PHYSFS_ErrorCode);
+
+typedef enum PHYSFS_EnumerateCallbackResult
+{
+	PHYSFS_ENUM_ERROR = -1,
+	PHYSFS_ENUM_STOP = 0,
+	PHYSFS_ENUM_OK = 1
+} PHYSFS_EnumerateCallbackResult;
+
+typedef PHYSFS_EnumerateCallbackResult (*PHYSFS_EnumerateCallback)(void *, const char *, const char *);
+
+int PHYSFS_enumerate(const char *, PHYSFS_EnumerateCallback, void *);
+int PHYSFS_deregisterArchiver(const char *);
+
+#endif
+
+#if !PHYSFS_AT_LEAST(2, 1)
+
+typedef enum PHYSFS_FileType
+{
+	PHYSFS_FILETYPE_REGULAR,
+	PHYSFS_FILETYPE_DIRECTORY,
+	PHYSFS_FILETYPE_SYMLINK,
+	PHYSFS_FILETYPE_OTHER
+} PHYSFS_FileType;
+
+typedef struct PHYSFS_Stat
+{
+	PHYSFS_sint64 filesize;
+	PHYSFS_sint64 modtime;
+	PHYSFS_sint64 createtime;
+	PHYSFS_sint64 accesstime;
+	PHYSFS_FileType filetype;
+	int readonly;
+} PHYSFS_Stat;
+
+typedef struct PHYSFS_Io
+{
+	PHYSFS_uint32 version;
+	void *opaque;
+	PHYSFS_sint64 (*read)(struct PHYSFS_Io *, void *, PHYSFS_uint64);
+	PHYSFS_sint64 (*write)(struct PHYSFS_Io *, const void *, PHYSFS_uint64);
+	int (*seek)(struct PHYSFS_Io *, PHYSFS_uint64);
+	PHYSFS_sint64 (*tell)(struct PHYSFS_Io *);
+	PHYSFS_sint64 (*length)(struct PHYSFS_Io *);
+	struct PHYSFS_Io *(*duplicate)(struct PHYSFS_Io *);
+	int (*flush)(struct PHYSFS_Io *);
+	void (*destroy)(struct PHYSFS_Io *);
+} PHYSFS_Io;
+
+int PHYSFS_stat(const char *, PHYSFS_Stat *);
+PHYSFS_sint64 PHYSFS_readBytes(PHYSFS_File *, void *, PHYSFS_uint64);
+PHYSFS_sint64 PHYSFS_writeBytes(PHYSFS_File *, const void *, PHYSFS_uint64);
+int PHYSFS_unmount(const char *);
+const char *PHYSFS_getPrefDir(const char *, const char *);
+int PHYSFS_mountIo(PHYSFS_Io *, const char *, const char *, int);
+int PHYSFS_mountMemory(const void *, PHYSFS_uint64, void (*)(void *), const char *, const char *, int);
+int PHYSFS_mountHandle(PHYSFS_File *, const char *, const char *, int);
+
+#endif
+
+#if !PHYSFS_AT_LEAST(3, 1)
+
+int PHYSFS_setRoot(const char *, const char *);
+
+#endif
+
+int archiveSupportsSymlinks(const PHYSFS_ArchiveInfo *);
+
+#endif
@@ -0,0 +1,186 @@
+package physfs
+
+import (
+	"errors"
+	"slices"
+)
+
+// A snapshot of the configuration of PhysicsFS: everything in the search path,
+// where it's mounted and any root set with SetRoot, the write dir, and whether
+// symbolic links are permitted. A Config is created with Snapshot() and put
+// back with Restore().
+type Config struct {
+	mounts   []searchPathEntry
+	writeDir string
+	symlinks bool
+}
+
+// Captures the current configuration. Anything mounted from memory with
+// MountMemory or MountReaderAt is kept alive by the returned Config, so that
+// it can be mounted again if it's removed in the meantime. Returns the
+// configuration and an error, if any.
+func Snapshot() (*Config, error) {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+
+	return snapshot()
+}
+
+// Captures the current configuration. The caller must hold orderLock.
+func snapshot() (*Config, error) {
+	sp, err := GetSearchPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{
+		mounts:   make([]searchPathEntry, 0, len(sp)),
+		writeDir: GetWriteDir(),
+		symlinks: SymbolicLinksPermitted(),
+	}
+	for _, source := range sp {
+		e, err := getSearchPathEntry(source)
+		if err != nil {
+			return nil, err
+		}
+		c.mounts = append(c.mounts, e)
+	}
+
+	return c, nil
+}
+
+// Returns the search path captured by the snapshot, in the order that it is
+// searched in.
+func (c *Config) SearchPath() []string {
+	sp := make([]string, 0, len(c.mounts))
+	for _, e := range c.mounts {
+		sp = append(sp, e.source)
+	}
+
+	return sp
+}
+
+// Returns the write dir captured by the snapshot, or "" if there wasn't one.
+func (c *Config) WriteDir() string {
+	return c.writeDir
+}
+
+// Returns whether symbolic links were permitted when the snapshot was taken.
+func (c *Config) SymbolicLinksPermitted() bool {
+	return c.symlinks
+}
+
+// Reinstates the configuration. Anything in the search path that isn't in c,
+// or that is mounted somewhere else, is removed, anything missing is mounted
+// again, and the search path is put back in the captured order. Only what has
+// changed is touched, so files from archives and directories that haven't
+// moved can stay open.
+//
+// This fails with ErrFilesStillOpen if files from anything that needs to be
+// removed are still open, and with ErrUnsupported if something mounted with
+// MountFile needs to be mounted again, as its handle is closed when it's
+// removed. If anything goes wrong, the previous configuration is restored as
+// far as possible. Returns an error, if any.
+func (c *Config) Restore() error {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+	b := new(batch)
+	defer b.end()
+
+	prev, err := snapshot()
+	if err != nil {
+		return err
+	}
+
+	if err := c.restore(b); err != nil {
+		return errors.Join(err, prev.restore(b))
+	}
+
+	return nil
+}
+
+// Reinstates the configuration, as part of b. The caller must hold orderLock.
+func (c *Config) restore(b *batch) error {
+	sp, err := GetSearchPath()
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]searchPathEntry, len(c.mounts))
+	for _, e := range c.mounts {
+		want[e.source] = e
+	}
+
+	var remove []string
+	for _, source := range sp {
+		e, err := getSearchPathEntry(source)
+		if err != nil {
+			return err
+		}
+
+		w, ok := want[source]
+		if ok && (w.mountPoint == e.mountPoint) && (w.root == e.root) {
+			continue
+		}
+		if files, _ := filesFrom(source); len(files) > 0 {
+			return &PathError{Op: "restore", Path: source, Code: ErrFilesStillOpen}
+		}
+		remove = append(remove, source)
+	}
+
+	var add []searchPathEntry
+	for _, e := range c.mounts {
+		if slices.Contains(sp, e.source) && !slices.Contains(remove, e.source) {
+			continue
+		}
+		if e.remount == nil {
+			return &PathError{Op: "restore", Path: e.source, Code: ErrUnsupported}
+		}
+		add = append(add, e)
+	}
+
+	if c.writeDir != GetWriteDir() {
+		if err := setWriteDir(b, c.writeDir); err != nil {
+			return err
+		}
+	}
+	permitSymbolicLinks(b, c.symlinks)
+
+	for _, source := range remove {
+		if err := unmount(b, source); err != nil {
+			return err
+		}
+	}
+	for _, e := range add {
+		if err := e.mount(b, true); err != nil {
+			return err
+		}
+	}
+
+	cur, err := GetSearchPath()
+	if err != nil {
+		return err
+	}
+
+	return reorder(b, "restore", cur, c.SearchPath())
+}
+
+// Applies the configuration c, calls fn, and then puts back the configuration
+// that was in place before, even if fn panics. If c can't be applied, fn isn't
+// called. Returns the error from fn, joined with any error from applying c or
+// putting back the previous configuration.
+func WithConfig(c *Config, fn func() error) (err error) {
+	prev, err := Snapshot()
+	if err != nil {
+		return err
+	}
+
+	if err := c.Restore(); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, prev.Restore())
+	}()
+
+	return fn()
+}
@@ -0,0 +1,89 @@
+package physfs
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	dir := setupWriteDir(t)
+	defer Deinit()
+
+	err := Mount("../test", "mod", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	cfg, err := Snapshot()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(cfg.SearchPath(), []string{dir, "../test"}) || (cfg.WriteDir() != dir) {
+		t.Fatalf("Unexpected snapshot: %v, %q\n", cfg.SearchPath(), cfg.WriteDir())
+	}
+
+	err = Unmount("../test")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/zip1.aoi", "", false)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test", "other", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = SetWriteDir("")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	PermitSymbolicLinks(!cfg.SymbolicLinksPermitted())
+
+	err = cfg.Restore()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	sp, err := GetSearchPath()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(sp, cfg.SearchPath()) {
+		t.Fatalf("Expected search path %v, got %v\n", cfg.SearchPath(), sp)
+	}
+	if mp, _ := GetMountPoint("../test"); mp != "mod/" {
+		t.Fatalf("Expected mount point \"mod/\", got %q\n", mp)
+	}
+	if (GetWriteDir() != dir) || (SymbolicLinksPermitted() != cfg.SymbolicLinksPermitted()) {
+		t.Fatalf("Settings not restored.\n")
+	}
+
+	called := false
+	err = WithConfig(&Config{}, func() error {
+		called = true
+		if sp, _ := GetSearchPath(); len(sp) != 0 {
+			t.Errorf("Unexpected search path: %v\n", sp)
+		}
+		return errors.New("fn")
+	})
+	if !called || (err == nil) || (err.Error() != "fn") {
+		t.Fatalf("Unexpected result: %v, %v\n", called, err)
+	}
+	if sp, _ := GetSearchPath(); !slices.Equal(sp, cfg.SearchPath()) {
+		t.Fatalf("Expected search path %v, got %v\n", cfg.SearchPath(), sp)
+	}
+
+	file, err := Open("mod/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+	err = (&Config{}).Restore()
+	if !errors.Is(err, ErrFilesStillOpen) {
+		t.Fatalf("Expected ErrFilesStillOpen, got %v\n", err)
+	}
+	if sp, _ := GetSearchPath(); !slices.Equal(sp, cfg.SearchPath()) {
+		t.Fatalf("Expected search path %v, got %v\n", cfg.SearchPath(), sp)
+	}
+}
@@ -0,0 +1,212 @@
+package physfs
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+// The size of the chunks that WriteTo and ReadFrom copy in, and of the buffer
+// that ReadFrom gives PhysicsFS while it writes them.
+const copyChunk = 256 * 1024
+
+var copyBufs = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyChunk)
+		return &buf
+	},
+}
+
+// Gives f a buffer of at least copyChunk bytes, if it doesn't already have
+// one, and returns a function that puts back the buffer that it had before. A
+// failure to set up the buffer only costs performance, so it's ignored, but
+// putting the old buffer back flushes it, so its error is returned. The caller
+// must hold f.lock and have locked the OS thread for both calls.
+func (f *File) growBuffer() (restore func() error) {
+	if (f.buffer >= copyChunk) || (int(C.PHYSFS_setBuffer(f.cfile, copyChunk)) == 0) {
+		return func() error { return nil }
+	}
+
+	return func() error {
+		if f.cfile == nil {
+			return nil
+		}
+
+		if int(C.PHYSFS_setBuffer(f.cfile, C.PHYSFS_uint64(f.buffer))) == 0 {
+			return lastError("setbuffer", f.name)
+		}
+		return nil
+	}
+}
+
+// Copies from src, which is open for reading, to dst, which is open for
+// writing, without the data passing through Go. Returns the number of bytes
+// copied and an error, if any.
+func copyFiles(dst, src *File) (int64, error) {
+	src.lock.Lock()
+	defer src.lock.Unlock()
+	dst.lock.Lock()
+	defer dst.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := src.check("read"); err != nil {
+		return 0, err
+	}
+	if err := dst.check("write"); err != nil {
+		return 0, err
+	}
+
+	restore := dst.growBuffer()
+	n := int64(C.copyFile(src.cfile, dst.cfile))
+	if n == -1 {
+		err := lastError("copy", src.name)
+		restore()
+		return 0, err
+	}
+
+	return n, restore()
+}
+
+func (f *File) readOnly() bool {
+	return f.flag&(os.O_WRONLY|os.O_RDWR) == 0
+}
+
+// Writes the rest of the file to w, in the same way as io.WriterTo. This is
+// used by io.Copy, and is much faster than copying with File.Read, as the file
+// is read in large chunks. If w is a File that is open for writing, the data is copied
+// without passing through Go at all. Returns the number of bytes written and an
+// error, if any.
+func (f *File) WriteTo(w io.Writer) (n int64, err error) {
+	if dst, ok := w.(*File); ok && f.readOnly() && !dst.readOnly() {
+		return copyFiles(dst, f)
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	f.lock.Lock()
+	err = f.check("read")
+	f.lock.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// The file is read in whole chunks, so a buffer in PhysicsFS would only
+	// add another copy of the data.
+	bufp := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(bufp)
+	buf := *bufp
+
+	for {
+		// The lock isn't held while writing to w, in case w uses the file.
+		f.lock.Lock()
+		read := -1
+		if f.cfile != nil {
+			read = readFull(f.cfile, buf)
+		}
+		closed := f.cfile == nil
+		f.lock.Unlock()
+
+		switch {
+		case closed:
+			return n, &PathError{Op: "read", Path: f.name, Code: ErrClosed}
+		case read == -1:
+			return n, lastError("read", f.name)
+		}
+
+		if read > 0 {
+			written, err := w.Write(buf[:read])
+			n += int64(written)
+			if err != nil {
+				return n, err
+			}
+			if written < read {
+				return n, io.ErrShortWrite
+			}
+		}
+
+		if read < len(buf) {
+			return n, nil
+		}
+	}
+}
+
+// Writes everything read from r to the file until r returns io.EOF, in the
+// same way as io.ReaderFrom. This is used by io.Copy, and is much faster than
+// copying with File.Write, as the file is written in large chunks, with
+// buffering enabled in PhysicsFS until the copy is finished. If r is a File
+// that is open for reading, the data is copied without passing through Go at
+// all. Returns the number of bytes read and an error, if any.
+func (f *File) ReadFrom(r io.Reader) (n int64, err error) {
+	if f.readOnly() {
+		return 0, &PathError{Op: "write", Path: f.name, Code: ErrOpenForReading}
+	}
+	if src, ok := r.(*File); ok && src.readOnly() {
+		return copyFiles(f, src)
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	f.lock.Lock()
+	if err := f.check("write"); err != nil {
+		f.lock.Unlock()
+		return 0, err
+	}
+	restore := f.growBuffer()
+	f.lock.Unlock()
+
+	defer func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		if rerr := restore(); err == nil {
+			err = rerr
+		}
+	}()
+
+	bufp := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(bufp)
+	buf := *bufp
+
+	for {
+		// The lock isn't held while reading from r, in case r uses the file.
+		read, rerr := r.Read(buf)
+
+		if read > 0 {
+			f.lock.Lock()
+			written := -1
+			if f.cfile != nil {
+				written = int(C.PHYSFS_writeBytes(f.cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(read)))
+			}
+			closed := f.cfile == nil
+			f.lock.Unlock()
+
+			switch {
+			case closed:
+				return n, &PathError{Op: "write", Path: f.name, Code: ErrClosed}
+			case written == -1:
+				return n, lastError("write", f.name)
+			}
+			n += int64(written)
+			if written < read {
+				return n, lastError("write", f.name)
+			}
+		}
+
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
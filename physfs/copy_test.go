@@ -0,0 +1,102 @@
+package physfs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	dir := setupWriteDir(t)
+	defer Deinit()
+
+	data := bytes.Repeat([]byte("0123456789"), 100000)
+
+	file, err := Create("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	n, err := io.Copy(file, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("Copied %v bytes, expected %v\n", n, len(data))
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err = Open("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	_, err = file.Seek(10, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, file)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data[10:]) {
+		t.Fatalf("Read %v bytes, expected %v\n", buf.Len(), len(data)-10)
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	out, err := Create("copy")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	_, err = io.Copy(out, file)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = out.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	copied, err := os.ReadFile(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("Copied data doesn't match\n")
+	}
+}
+
+func benchmarkCopy(b *testing.B, wrap bool) {
+	setupBenchmark(b, 16<<20)
+	defer Deinit()
+
+	for i := 0; i < b.N; i++ {
+		file, err := Open("bench")
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+
+		var r io.Reader = file
+		if wrap {
+			// Hides WriteTo, so that io.Copy falls back to File.Read.
+			r = struct{ io.Reader }{file}
+		}
+		_, err = io.Copy(io.Discard, r)
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+
+		file.Close()
+	}
+}
+
+func BenchmarkWriteTo(b *testing.B)  { benchmarkCopy(b, false) }
+func BenchmarkCopyRead(b *testing.B) { benchmarkCopy(b, true) }
@@ -0,0 +1,177 @@
+package physfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+)
+
+// Returns the sorted names of the entries in the directory name in the search
+// path, with each name appearing once even if it is provided by several
+// archives or directories. name may also be a symbolic link to a directory, if
+// symbolic links are permitted.
+func readDirNames(name string) ([]string, error) {
+	info, err := Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() && !linksToDir(name, info) {
+		return nil, &PathError{Op: "readdir", Path: name, Code: ErrInvalidArgument}
+	}
+
+	names, err := EnumerateFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	if names == nil {
+		names = []string{}
+	}
+	slices.Sort(names)
+
+	return slices.Compact(names), nil
+}
+
+// Returns whether name, which info describes, is a symbolic link that
+// PhysicsFS will follow to a directory. PhysicsFS reports links themselves
+// rather than what they point to, so this is only known for links in native
+// directories.
+func linksToDir(name string, info os.FileInfo) bool {
+	if (info.Mode()&fs.ModeSymlink == 0) || !SymbolicLinksPermitted() {
+		return false
+	}
+
+	return len(nativeDirs(name)) > 0
+}
+
+// Returns the entries of dir named by names, skipping any that disappeared
+// after being listed.
+func dirEntries(dir string, names []string) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, name := range names {
+		info, err := Stat(path.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+
+	return entries
+}
+
+// Returns all of the entries of the named directory in the search path, sorted
+// by name. Entries provided by several archives or directories in the search
+// path only appear once. Returns the entries and an error, if any.
+func ReadDir(name string) ([]fs.DirEntry, error) {
+	names, err := readDirNames(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return dirEntries(name, names), nil
+}
+
+// The state of a File that is a directory.
+type dirHandle struct {
+	names  []string
+	read   int
+	closed bool
+}
+
+// Returns the next names from the directory f, following the semantics of
+// os.File.Readdirnames. The directory is listed on the first call, and later
+// calls page through that listing. f must be locked.
+func (f *File) nextNames(op string, n int) ([]string, error) {
+	d := f.dir
+	if d == nil {
+		return nil, &PathError{Op: op, Path: f.name, Code: ErrInvalidArgument}
+	}
+	if d.closed {
+		return nil, &PathError{Op: op, Path: f.name, Code: ErrClosed}
+	}
+
+	if d.names == nil {
+		names, err := readDirNames(f.name)
+		if err != nil {
+			return nil, err
+		}
+		d.names = names
+	}
+
+	remaining := d.names[d.read:]
+	if n <= 0 {
+		d.read = len(d.names)
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(remaining))
+	d.read += n
+
+	return remaining[:n:n], nil
+}
+
+// Reads the contents of the directory f and returns up to n names from it, in
+// sorted order, following the semantics of os.File.Readdirnames. If n > 0, an
+// empty slice and io.EOF are returned at the end of the directory. If n <= 0,
+// all of the remaining names are returned at once. Returns the names and an
+// error, if any.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.nextNames("readdirnames", n)
+}
+
+// Returns the entries for the next names from the directory f, in the same way
+// as nextNames. Names whose entries disappeared after being listed are skipped,
+// and further names are fetched in their place, so that a page is only empty at
+// the end of the directory. f must be locked.
+func (f *File) nextEntries(op string, n int) ([]fs.DirEntry, error) {
+	for {
+		names, err := f.nextNames(op, n)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := dirEntries(f.name, names)
+		if (n <= 0) || (len(entries) > 0) {
+			return entries, nil
+		}
+	}
+}
+
+// Like File.Readdirnames, but returns fs.DirEntry values.
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.nextEntries("readdir", n)
+}
+
+// Like File.Readdirnames, but returns os.FileInfo values.
+func (f *File) Readdir(n int) ([]os.FileInfo, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	entries, err := f.nextEntries("readdir", n)
+	if err != nil {
+		return nil, err
+	}
+
+	fi := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fi = append(fi, info)
+	}
+
+	return fi, nil
+}
@@ -0,0 +1,125 @@
+package physfs
+
+import (
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestReadDir(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	data, err := os.ReadFile("../test/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MountMemory(data, "copy.zip", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/a.zip", "a", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	entries, err := ReadDir("")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (len(entries) != 2) || (entries[0].Name() != "a") || (entries[1].Name() != "dir1") || !entries[1].IsDir() {
+		t.Fatalf("Unexpected entries: %v\n", entries)
+	}
+
+	dir, err := Open("a")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer dir.Close()
+
+	var names []string
+	for {
+		page, err := dir.Readdirnames(2)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("Page too big: %v\n", page)
+		}
+		names = append(names, page...)
+	}
+	if !slices.Equal(names, []string{"hello-world.go", "index.html", "physfs-server"}) {
+		t.Fatalf("Unexpected names: %v\n", names)
+	}
+
+	dir2, err := Open("a")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer dir2.Close()
+
+	fi, err := dir2.Readdir(-1)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (len(fi) != 3) || (fi[1].Name() != "index.html") || (fi[1].Size() != 5) {
+		t.Fatalf("Unexpected info: %v\n", fi)
+	}
+	fi, err = dir2.Readdir(-1)
+	if (err != nil) || (len(fi) != 0) {
+		t.Fatalf("Expected nothing, got %v, %v\n", fi, err)
+	}
+
+	_, err = ReadDir("a/index.html")
+	if err == nil {
+		t.Fatalf("Expected error reading file as directory.\n")
+	}
+}
+
+func TestReadDirVanished(t *testing.T) {
+	setupWriteDir(t)
+	defer Deinit()
+
+	for _, name := range []string{"a", "b", "c"} {
+		err := WriteFile(name, []byte(name))
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+
+	dir, err := Open("")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer dir.Close()
+
+	entries, err := dir.ReadDir(1)
+	if (err != nil) || (len(entries) != 1) || (entries[0].Name() != "a") {
+		t.Fatalf("Unexpected result: %v, %v\n", entries, err)
+	}
+
+	for _, name := range []string{"b", "c"} {
+		err := Delete(name)
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+
+	entries, err = dir.ReadDir(1)
+	if (err != io.EOF) || (len(entries) != 0) {
+		t.Fatalf("Expected io.EOF, got %v, %v\n", entries, err)
+	}
+}
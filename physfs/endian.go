@@ -0,0 +1,326 @@
+package physfs
+
+import (
+	"encoding/binary"
+	"io"
+	"runtime"
+	"unsafe"
+)
+
+// #include "compat.h"
+import "C"
+
+// Reads exactly len(buf) bytes from the file into buf with a single read. If
+// the file ends first, the error is io.EOF if nothing was read and
+// io.ErrUnexpectedEOF otherwise, as with io.ReadFull.
+func (f *File) readExact(buf []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("read"); err != nil {
+		return err
+	}
+
+	n := readFull(f.cfile, buf)
+	switch {
+	case n == -1:
+		return lastError("read", f.name)
+	case n == 0:
+		return io.EOF
+	case n < len(buf):
+		return io.ErrUnexpectedEOF
+	}
+
+	return nil
+}
+
+// Writes all of buf to the file with a single write.
+func (f *File) writeExact(buf []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("write"); err != nil {
+		return err
+	}
+
+	n := int(C.PHYSFS_writeBytes(f.cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(len(buf))))
+	if n < len(buf) {
+		return lastError("write", f.name)
+	}
+
+	return nil
+}
+
+// Reads a fixed-size value from the file into v, which must be a pointer to a
+// fixed-size value or a slice of fixed-size values, as with binary.Read. The
+// whole value is read with a single read and then decoded using order. If the
+// file ends before all of it is read, the error is io.EOF if nothing was read
+// and io.ErrUnexpectedEOF otherwise, as with io.ReadFull. Returns an error, if
+// any.
+func (f *File) ReadStruct(order binary.ByteOrder, v interface{}) error {
+	size := binary.Size(v)
+	if size < 0 {
+		return &PathError{Op: "read", Path: f.name, Code: ErrInvalidArgument}
+	}
+	if size == 0 {
+		return nil
+	}
+
+	buf := make([]byte, size)
+	if err := f.readExact(buf); err != nil {
+		return err
+	}
+
+	_, err := binary.Decode(buf, order, v)
+	return err
+}
+
+// Encodes v using order, as with binary.Write, and writes it to the file with
+// a single write. Returns an error, if any.
+func (f *File) WriteStruct(order binary.ByteOrder, v interface{}) error {
+	if binary.Size(v) < 0 {
+		return &PathError{Op: "write", Path: f.name, Code: ErrInvalidArgument}
+	}
+
+	buf, err := binary.Append(nil, order, v)
+	if err != nil {
+		return err
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+
+	return f.writeExact(buf)
+}
+
+// Reads a little-endian uint16 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint16LE() (uint16, error) {
+	var buf [2]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint16(buf[:]), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteUint16LE(v uint16) error {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian uint16 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint16BE() (uint16, error) {
+	var buf [2]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(buf[:]), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteUint16BE(v uint16) error {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a little-endian int16 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt16LE() (int16, error) {
+	var buf [2]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int16(binary.LittleEndian.Uint16(buf[:])), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteInt16LE(v int16) error {
+	var buf [2]byte
+	binary.LittleEndian.PutUint16(buf[:], uint16(v))
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian int16 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt16BE() (int16, error) {
+	var buf [2]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int16(binary.BigEndian.Uint16(buf[:])), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteInt16BE(v int16) error {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], uint16(v))
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a little-endian uint32 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint32LE() (uint32, error) {
+	var buf [4]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(buf[:]), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteUint32LE(v uint32) error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian uint32 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint32BE() (uint32, error) {
+	var buf [4]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteUint32BE(v uint32) error {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a little-endian int32 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt32LE() (int32, error) {
+	var buf [4]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int32(binary.LittleEndian.Uint32(buf[:])), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteInt32LE(v int32) error {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(v))
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian int32 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt32BE() (int32, error) {
+	var buf [4]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int32(binary.BigEndian.Uint32(buf[:])), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteInt32BE(v int32) error {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(v))
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a little-endian uint64 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint64LE() (uint64, error) {
+	var buf [8]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteUint64LE(v uint64) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian uint64 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadUint64BE() (uint64, error) {
+	var buf [8]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteUint64BE(v uint64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a little-endian int64 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt64LE() (int64, error) {
+	var buf [8]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+// Writes v to the file in little-endian byte order. Returns an error, if any.
+func (f *File) WriteInt64LE(v int64) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(v))
+
+	return f.writeExact(buf[:])
+}
+
+// Reads a big-endian int64 from the file. Short reads are reported in
+// the same way as by ReadStruct. Returns the value and an error, if any.
+func (f *File) ReadInt64BE() (int64, error) {
+	var buf [8]byte
+	if err := f.readExact(buf[:]); err != nil {
+		return 0, err
+	}
+
+	return int64(binary.BigEndian.Uint64(buf[:])), nil
+}
+
+// Writes v to the file in big-endian byte order. Returns an error, if any.
+func (f *File) WriteInt64BE(v int64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+
+	return f.writeExact(buf[:])
+}
@@ -0,0 +1,77 @@
+package physfs
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestEndian(t *testing.T) {
+	setupWriteDir(t)
+	defer Deinit()
+
+	type header struct {
+		Magic   [4]byte
+		Version uint16
+		Count   int32
+	}
+
+	file, err := Create("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.WriteUint16LE(0x1234)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.WriteInt32BE(-2)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.WriteUint64LE(0x0102030405060708)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.WriteStruct(binary.BigEndian, header{Magic: [4]byte{'M', 'A', 'P', 0}, Version: 3, Count: -7})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.WriteUint16BE(1)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	file.Close()
+
+	file, err = Open("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	u16, err := file.ReadUint16LE()
+	if (err != nil) || (u16 != 0x1234) {
+		t.Fatalf("Unexpected result: %#x, %v\n", u16, err)
+	}
+	i32, err := file.ReadInt32BE()
+	if (err != nil) || (i32 != -2) {
+		t.Fatalf("Unexpected result: %v, %v\n", i32, err)
+	}
+	u64, err := file.ReadUint64LE()
+	if (err != nil) || (u64 != 0x0102030405060708) {
+		t.Fatalf("Unexpected result: %#x, %v\n", u64, err)
+	}
+	var h header
+	err = file.ReadStruct(binary.BigEndian, &h)
+	if (err != nil) || (string(h.Magic[:3]) != "MAP") || (h.Version != 3) || (h.Count != -7) {
+		t.Fatalf("Unexpected result: %+v, %v\n", h, err)
+	}
+
+	_, err = file.ReadUint32LE()
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v\n", err)
+	}
+	_, err = file.ReadUint16LE()
+	if err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v\n", err)
+	}
+}
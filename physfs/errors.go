@@ -0,0 +1,164 @@
+package physfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// #include "compat.h"
+import "C"
+
+// An error code reported by PhysicsFS. ErrorCode implements error, and can be
+// compared against the standard errors in io/fs using errors.Is:
+//
+//	ErrNotFound, ErrNotMounted                       fs.ErrNotExist
+//	ErrPermission, ErrReadOnly, ErrSymlinkForbidden  fs.ErrPermission
+//	ErrDuplicate                                     fs.ErrExist
+//	ErrClosed                                        fs.ErrClosed
+//	ErrInvalidArgument, ErrBadFilename               fs.ErrInvalid
+type ErrorCode int
+
+// The values of these match those of PHYSFS_ErrorCode, except for ErrClosed,
+// which is used by the bindings for operations on closed files and doesn't
+// exist in PhysicsFS.
+const (
+	ErrOK ErrorCode = iota
+	ErrOtherError
+	ErrOutOfMemory
+	ErrNotInitialized
+	ErrIsInitialized
+	ErrArgv0IsNull
+	ErrUnsupported
+	ErrPastEOF
+	ErrFilesStillOpen
+	ErrInvalidArgument
+	ErrNotMounted
+	ErrNotFound
+	ErrSymlinkForbidden
+	ErrNoWriteDir
+	ErrOpenForReading
+	ErrOpenForWriting
+	ErrNotAFile
+	ErrReadOnly
+	ErrCorrupt
+	ErrSymlinkLoop
+	ErrIO
+	ErrPermission
+	ErrNoSpace
+	ErrBadFilename
+	ErrBusy
+	ErrDirNotEmpty
+	ErrOSError
+	ErrDuplicate
+	ErrBadPassword
+	ErrAppCallback
+
+	ErrClosed ErrorCode = -1
+)
+
+func (c ErrorCode) Error() string {
+	if c == ErrClosed {
+		return "file already closed"
+	}
+
+	return C.GoString(C.PHYSFS_getErrorByCode(C.PHYSFS_ErrorCode(c)))
+}
+
+func (c ErrorCode) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return (c == ErrNotFound) || (c == ErrNotMounted)
+	case fs.ErrPermission:
+		return (c == ErrPermission) || (c == ErrReadOnly) || (c == ErrSymlinkForbidden)
+	case fs.ErrExist:
+		return c == ErrDuplicate
+	case fs.ErrClosed:
+		return c == ErrClosed
+	case fs.ErrInvalid:
+		return (c == ErrInvalidArgument) || (c == ErrBadFilename)
+	}
+
+	return false
+}
+
+// The type of error returned by most functions in this package. It records the
+// operation that failed, the path that it failed on, if any, and the PhysicsFS
+// error code describing the failure. Use errors.Is to compare it against
+// either an ErrorCode or one of the standard errors in io/fs.
+type PathError struct {
+	Op   string
+	Path string
+	Code ErrorCode
+}
+
+func (e *PathError) Error() string {
+	if e.Path == "" {
+		return e.Op + ": " + e.Code.Error()
+	}
+
+	return e.Op + " " + e.Path + ": " + e.Code.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Code
+}
+
+// Returned by functions that call back into Go code when the callback panics,
+// as a panic can't be allowed to unwind through PhysicsFS. Value is the value
+// that was passed to panic.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("callback panicked: %v", e.Value)
+}
+
+// Returns a *PathError for op on path with the error code of the last error
+// that occured in PhysicsFS. PhysicsFS keeps track of errors per thread, so the
+// caller must have locked the goroutine to its OS thread with
+// runtime.LockOSThread() since before the operation that failed.
+func lastError(op, path string) error {
+	code := ErrorCode(C.PHYSFS_getLastErrorCode())
+	if code == ErrOK {
+		// Something failed without saying why.
+		code = ErrOtherError
+	}
+
+	return &PathError{
+		Op:   op,
+		Path: path,
+		Code: code,
+	}
+}
+
+// Sets the PhysicsFS error code to something that describes err, which was
+// returned by Go code called from PhysicsFS.
+func setErrorCode(err error) {
+	var code ErrorCode
+	switch {
+	case errors.As(err, &code) && (code > ErrOK):
+	case errors.Is(err, fs.ErrNotExist):
+		code = ErrNotFound
+	case errors.Is(err, fs.ErrPermission):
+		code = ErrPermission
+	case errors.Is(err, ErrNotArchive):
+		code = ErrUnsupported
+	default:
+		code = ErrOtherError
+	}
+
+	C.PHYSFS_setErrorCode(C.PHYSFS_ErrorCode(code))
+}
+
+// Recovers from a panic in Go code called by PhysicsFS through one of its own
+// interfaces, such as an Archiver, which have no way to return the panic to the
+// caller. The error code is set to ErrAppCallback and fail is called so that
+// the failure can be reported to PhysicsFS. Must be deferred directly.
+func recoverAppCallback(fail func()) {
+	if recover() != nil {
+		C.PHYSFS_setErrorCode(C.PHYSFS_ErrorCode(ErrAppCallback))
+		fail()
+	}
+}
@@ -0,0 +1,62 @@
+package physfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	_, err = Open("does/not/exist")
+	if !errors.Is(err, fs.ErrNotExist) || !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected not found, got %v\n", err)
+	}
+	var pe *PathError
+	if !errors.As(err, &pe) || (pe.Op != "open") || (pe.Path != "does/not/exist") {
+		t.Fatalf("Unexpected error: %#v\n", err)
+	}
+
+	err = RemoveFromSearchPath("not-mounted.zip")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected not mounted, got %v\n", err)
+	}
+
+	_, err = Create("file")
+	if !errors.Is(err, ErrNoWriteDir) {
+		t.Fatalf("Expected no write dir, got %v\n", err)
+	}
+
+	file, err := Open("dir1/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	_, err = file.Seek(0, 3)
+	if !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected invalid, got %v\n", err)
+	}
+	err = file.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.Close()
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("Expected closed, got %v\n", err)
+	}
+	_, err = file.Read(make([]byte, 1))
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("Expected closed, got %v\n", err)
+	}
+}
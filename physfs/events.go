@@ -0,0 +1,216 @@
+package physfs
+
+import (
+	"sync"
+)
+
+// The kind of change described by an Event.
+type EventKind int
+
+const (
+	// Something was added to the search path.
+	EventMount EventKind = iota
+
+	// Something was removed from the search path.
+	EventUnmount
+
+	// The root of something in the search path was changed with SetRoot.
+	EventSetRoot
+
+	// The write dir was changed.
+	EventWriteDir
+
+	// Whether symbolic links are permitted was changed.
+	EventSymbolicLinks
+
+	// Several changes were made at once, such as by MountAt, MoveMount,
+	// SetMountOrder, Config.Restore or SetSaneConfig, and anything may have
+	// changed.
+	EventConfig
+
+	// PhysicsFS was deinitialized, clearing the search path and write dir.
+	EventDeinit
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventMount:
+		return "mount"
+	case EventUnmount:
+		return "unmount"
+	case EventSetRoot:
+		return "setroot"
+	case EventWriteDir:
+		return "writedir"
+	case EventSymbolicLinks:
+		return "symboliclinks"
+	case EventConfig:
+		return "config"
+	case EventDeinit:
+		return "deinit"
+	}
+
+	return "unknown"
+}
+
+// Describes a change to the search path, the write dir or the settings that
+// affect what paths resolve to.
+type Event struct {
+	Kind EventKind
+
+	// The archive or directory that was mounted, unmounted or had its root
+	// changed, or the new write dir, which is "" if there is none. Empty for
+	// other kinds of events.
+	Path string
+
+	// Where the archive or directory was mounted, for EventMount.
+	MountPoint string
+
+	// The generation that the change produced, as returned by Generation().
+	Generation uint64
+}
+
+type subscriber struct {
+	fn func(Event)
+
+	lock      sync.Mutex
+	queue     []Event
+	running   bool
+	cancelled bool
+}
+
+var changes struct {
+	sync.Mutex
+
+	generation  uint64
+	subscribers []*subscriber
+}
+
+// Collects the changes made by something that makes several changes at once,
+// such as MountAt, so that they're announced together when it's done. It's
+// passed down to the functions that make the changes, so that changes made by
+// other goroutines in the meantime are still announced as usual. A nil *batch
+// announces each change as it's made.
+type batch struct {
+	changed bool
+
+	// Set for changes that are undone, or announced in some other way,
+	// straight afterwards, which don't advance the generation and are never
+	// announced.
+	quiet bool
+}
+
+// Returns a batch for changes that are undone, or announced in some other way,
+// straight afterwards.
+func quietBatch() *batch {
+	return &batch{quiet: true}
+}
+
+// Returns the current generation of the search path. It starts at 0 and
+// increases every time something changes that could affect what a path
+// resolves to, before the change is announced to the functions passed to
+// OnChange. A cache can record the generation when it loads something, and
+// treat it as stale once the generation differs.
+func Generation() uint64 {
+	changes.Lock()
+	defer changes.Unlock()
+
+	return changes.generation
+}
+
+// Calls fn with an Event each time the search path, the write dir or the
+// settings that affect what paths resolve to are changed through this package.
+// Events are delivered in order, from a separate goroutine, so fn may call
+// any function in this package, but by the time it is called the change may
+// have been followed by others; Generation() can be used to tell. Returns a
+// function that stops fn from being called, including for events that have
+// not been delivered yet.
+func OnChange(fn func(Event)) (cancel func()) {
+	s := &subscriber{fn: fn}
+
+	changes.Lock()
+	changes.subscribers = append(changes.subscribers, s)
+	changes.Unlock()
+
+	return func() {
+		changes.Lock()
+		for i, sub := range changes.subscribers {
+			if sub == s {
+				changes.subscribers = append(changes.subscribers[:i:i], changes.subscribers[i+1:]...)
+				break
+			}
+		}
+		changes.Unlock()
+
+		s.lock.Lock()
+		s.cancelled = true
+		s.queue = nil
+		s.lock.Unlock()
+	}
+}
+
+func (s *subscriber) send(ev Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.cancelled {
+		return
+	}
+
+	s.queue = append(s.queue, ev)
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+func (s *subscriber) run() {
+	for {
+		s.lock.Lock()
+		if len(s.queue) == 0 {
+			s.running = false
+			s.lock.Unlock()
+			return
+		}
+		ev := s.queue[0]
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		s.fn(ev)
+	}
+}
+
+// Advances the generation and announces ev, unless it's part of the batch b,
+// in which case a single EventConfig is announced when the batch ends.
+func notify(b *batch, ev Event) {
+	if (b != nil) && b.quiet {
+		return
+	}
+
+	changes.Lock()
+	defer changes.Unlock()
+
+	changes.generation++
+	if b != nil {
+		b.changed = true
+		return
+	}
+
+	ev.Generation = changes.generation
+	for _, s := range changes.subscribers {
+		s.send(ev)
+	}
+}
+
+// Ends the batch b, announcing an EventConfig if anything changed.
+func (b *batch) end() {
+	if b.changed {
+		notify(nil, Event{Kind: EventConfig})
+	}
+}
+
+// Announces that source has been added to the search path, as part of b.
+func notifyMount(b *batch, source string) {
+	mp, _ := GetMountPoint(source)
+	notify(b, Event{Kind: EventMount, Path: source, MountPoint: mp})
+}
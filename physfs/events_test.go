@@ -0,0 +1,126 @@
+package physfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnChange(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+
+	events := make(chan Event, 16)
+	cancel := OnChange(func(ev Event) {
+		events <- ev
+	})
+	defer cancel()
+
+	next := func(kind EventKind, path string) Event {
+		t.Helper()
+
+		select {
+		case ev := <-events:
+			if (ev.Kind != kind) || (ev.Path != path) {
+				t.Fatalf("Expected %v event for %q, got %+v\n", kind, path, ev)
+			}
+			return ev
+		case <-time.After(time.Second):
+			t.Fatalf("No %v event.\n", kind)
+		}
+		return Event{}
+	}
+
+	gen := Generation()
+	err := Mount("../test", "mod", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	ev := next(EventMount, "../test")
+	if (ev.MountPoint != "mod/") || (ev.Generation <= gen) || (ev.Generation != Generation()) {
+		t.Fatalf("Unexpected event: %+v\n", ev)
+	}
+
+	// Mounting it again does nothing, so shouldn't be announced.
+	err = Mount("../test", "mod", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	next(EventMount, "../test/zip1.aoi")
+
+	err = MoveMount("../test/zip1.aoi", 0)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	next(EventConfig, "")
+
+	err = RemoveFromSearchPath("../test")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	next(EventUnmount, "../test")
+
+	err = Deinit()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	ev = next(EventDeinit, "")
+	if ev.Generation != Generation() {
+		t.Fatalf("Expected generation %v, got %v\n", Generation(), ev.Generation)
+	}
+
+	cancel()
+	defer PermitSymbolicLinks(SymbolicLinksPermitted())
+	PermitSymbolicLinks(!SymbolicLinksPermitted())
+	select {
+	case ev := <-events:
+		t.Fatalf("Unexpected event after cancel: %+v\n", ev)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestBatchScope(t *testing.T) {
+	events := make(chan Event, 16)
+	cancel := OnChange(func(ev Event) {
+		events <- ev
+	})
+	defer cancel()
+
+	b := new(batch)
+	notify(b, Event{Kind: EventMount, Path: "batched"})
+
+	// Changes made elsewhere while the batch is in progress are announced
+	// straight away.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		notify(nil, Event{Kind: EventWriteDir, Path: "other"})
+	}()
+	<-done
+
+	select {
+	case ev := <-events:
+		if (ev.Kind != EventWriteDir) || (ev.Path != "other") {
+			t.Fatalf("Unexpected event: %+v\n", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Change outside of the batch wasn't announced.\n")
+	}
+
+	b.end()
+	select {
+	case ev := <-events:
+		if ev.Kind != EventConfig {
+			t.Fatalf("Unexpected event: %+v\n", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Batch wasn't announced.\n")
+	}
+}
@@ -2,34 +2,53 @@ package physfs
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"syscall"
-	"time"
 	"unsafe"
 )
 
 // #include <stdlib.h>
-// #include <physfs.h>
+// #include "compat.h"
 import "C"
 
 // A type for PhysicsFS file operations. Designed to be as compatible as
 // possible with os.File.
+//
+// A File may be used from several goroutines at once, with operations on it
+// being performed one at a time.
 type File struct {
+	lock  sync.Mutex
 	cfile *C.PHYSFS_File
 
-	name string
-	read int
+	name   string
+	flag   int
+	dir    *dirHandle
+	buffer uint64
+
+	// The archive or directory in the search path that a file opened for
+	// reading comes from.
+	source string
+
+	// Extra handles on the same file, used by ReadAt so that it doesn't
+	// disturb the offset of cfile and can be called concurrently.
+	readers []*C.PHYSFS_File
+
+	// Set when PhysicsFS was deinitialized while the file was open, which
+	// closes every handle on it.
+	deinit bool
 }
 
 // Open the named file, relative to the current write dir, for writing. The
 // specified file is created if it doesn't exist. If it does exist it is
 // truncated to zero bytes. Returns the file and an error, if any.
 func Create(name string) (file *File, err error) {
-	return openFile(name, os.O_WRONLY)
+	return openFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
 }
 
 // Open the named file, relative the current write dir, for writing. The
@@ -37,7 +56,7 @@ func Create(name string) (file *File, err error) {
 // offset is set to the end of the file so that writes will append to the file.
 // Returns the file and an error, if any.
 func Append(name string) (file *File, err error) {
-	return openFile(name, os.O_APPEND)
+	return openFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
 }
 
 // Open the named file from the search path for reading. Returns the file and an
@@ -46,70 +65,271 @@ func Open(name string) (file *File, err error) {
 	return openFile(name, os.O_RDONLY)
 }
 
-// Open the named file with the mode specified by flag. Read-only files are
-// opened from the search-path, and write-only and append-only files are opened
-// relative to the current write directory. Returns the file and an error, if
-// any.
+// An option that configures a file opened by OpenFile.
+type OpenOption func(f *File) error
+
+// Returns an OpenOption that sets up a buffer of size bytes for the file, as
+// with File.SetBuffer.
+func WithBuffer(size uint64) OpenOption {
+	return func(f *File) error {
+		return f.SetBuffer(size)
+	}
+}
+
+// Open the named file with the mode specified by flag, in the same way as
+// os.OpenFile, and then apply opts to it in order. Files opened with
+// os.O_RDONLY are opened from the search path. With os.O_CREATE, a file that
+// isn't in the search path is first created, empty, in the write dir, which
+// must itself be in the search path for the file to be found afterwards; if
+// it isn't, the file is removed again. os.O_EXCL fails if it's already there; os.O_TRUNC and os.O_APPEND are
+// incompatible with os.O_RDONLY. Files opened with os.O_WRONLY are opened
+// relative to the write dir, and the following flags are supported with it:
+//
+//	os.O_CREATE  create the file if it doesn't exist
+//	os.O_EXCL    with os.O_CREATE, fail if the file already exists
+//	os.O_TRUNC   truncate the file to zero bytes
+//	os.O_APPEND  append to the end of the file
+//
+// PhysicsFS can't open files for both reading and writing, so os.O_RDWR isn't
+// supported. It also can't open an existing file for writing without either
+// truncating or appending to it, so os.O_WRONLY without os.O_TRUNC or
+// os.O_APPEND only succeeds if the file is empty or doesn't exist. Other flags,
+// such as os.O_SYNC, are ignored. Returns the file and an error, if any. If an
+// option fails, the file is closed and the option's error is returned.
+func OpenFile(name string, flag int, opts ...OpenOption) (*File, error) {
+	if err := checkOpenFlag(name, flag); err != nil {
+		return nil, err
+	}
+
+	var created bool
+	if (flag&(os.O_WRONLY|os.O_RDWR) == 0) && (flag&os.O_CREATE != 0) && !Exists(name) {
+		var err error
+		created, err = createEmpty(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := openFile(name, flag)
+	if err != nil {
+		// Such as if the write dir isn't in the search path.
+		if created {
+			Delete(name)
+		}
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// Checks that flag is a combination that OpenFile supports for name, and that
+// name's existence in the search path, for reading, or the write dir, for
+// writing, matches what flag expects of it.
+func checkOpenFlag(name string, flag int) error {
+	switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+	case os.O_RDONLY:
+		if flag&(os.O_TRUNC|os.O_APPEND) != 0 {
+			return &PathError{Op: "open", Path: name, Code: ErrInvalidArgument}
+		}
+		if flag&os.O_CREATE == 0 {
+			return nil
+		}
+		if Exists(name) {
+			if flag&os.O_EXCL != 0 {
+				return &PathError{Op: "open", Path: name, Code: ErrDuplicate}
+			}
+			return nil
+		}
+		if GetWriteDir() == "" {
+			return &PathError{Op: "open", Path: name, Code: ErrNoWriteDir}
+		}
+		return nil
+	case os.O_WRONLY:
+	case os.O_RDWR:
+		return &PathError{Op: "open", Path: name, Code: ErrUnsupported}
+	default:
+		return &PathError{Op: "open", Path: name, Code: ErrInvalidArgument}
+	}
+
+	dir := GetWriteDir()
+	if dir == "" {
+		return &PathError{Op: "open", Path: name, Code: ErrNoWriteDir}
+	}
+
+	info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+	switch {
+	case err == nil:
+		if (flag&os.O_CREATE != 0) && (flag&os.O_EXCL != 0) {
+			return &PathError{Op: "open", Path: name, Code: ErrDuplicate}
+		}
+		if info.IsDir() {
+			return &PathError{Op: "open", Path: name, Code: ErrNotAFile}
+		}
+		if (flag&(os.O_TRUNC|os.O_APPEND) == 0) && (info.Size() > 0) {
+			return &PathError{Op: "open", Path: name, Code: ErrUnsupported}
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if flag&os.O_CREATE == 0 {
+			return &PathError{Op: "open", Path: name, Code: ErrNotFound}
+		}
+	default:
+		return err
+	}
+
+	return nil
+}
+
+// Creates the named file in the write dir, empty, if it doesn't already exist
+// there. Returns whether the file was created and an error, if any.
+func createEmpty(name string) (created bool, err error) {
+	if _, err := os.Stat(filepath.Join(GetWriteDir(), filepath.FromSlash(name))); err == nil {
+		return false, nil
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	// Appending creates the file without truncating it if it's there.
+	cfile := C.PHYSFS_openAppend(cname)
+	if cfile == nil {
+		return false, lastError("open", name)
+	}
+	if int(C.PHYSFS_close(cfile)) == 0 {
+		return true, lastError("close", name)
+	}
+
+	return true, nil
+}
+
+// Open the named file with the mode specified by flag, which is assumed to
+// have been checked already. Read-only files are opened from the search-path,
+// and write-only files are opened relative to the current write directory,
+// either for appending or truncated. Returns the file and an error, if any.
 func openFile(name string, flag int) (f *File, err error) {
-	if IsDirectory(name) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	read := flag&(os.O_WRONLY|os.O_RDWR) == 0
+	if read && IsDirectory(name) {
 		return &File{
-			nil,
-			name,
-			0,
+			name: name,
+			flag: flag,
+			dir:  new(dirHandle),
 		}, nil
 	}
 
 	cname := C.CString(name)
 	defer C.free(unsafe.Pointer(cname))
-	switch flag {
-	case os.O_RDONLY:
-		f = &File{C.PHYSFS_openRead(cname), name, -1}
-	case os.O_WRONLY:
-		f = &File{C.PHYSFS_openWrite(cname), name, -1}
-	case os.O_APPEND:
-		f = &File{C.PHYSFS_openAppend(cname), name, -1}
+	switch {
+	case read:
+		f = &File{cfile: C.PHYSFS_openRead(cname), name: name, flag: flag}
+	case flag&os.O_APPEND != 0:
+		f = &File{cfile: C.PHYSFS_openAppend(cname), name: name, flag: flag}
 	default:
-		return nil, errors.New("Unknown flag.")
+		f = &File{cfile: C.PHYSFS_openWrite(cname), name: name, flag: flag}
 	}
 
 	if f.cfile == nil {
-		return nil, errors.New(GetLastError())
+		return nil, lastError("open", name)
+	}
+
+	if read {
+		if dir := C.PHYSFS_getRealDir(cname); dir != nil {
+			f.source = C.GoString(dir)
+		}
 	}
+	trackFile(f)
 
 	return
 }
 
+// Reports whether f is a directory. This is decided when f is opened, so a
+// file doesn't become a directory if one with the same name is mounted later.
 func (f *File) isdir() bool {
-	return IsDirectory(f.name)
+	return f.dir != nil
+}
+
+// Returns an error if op can't be performed on f, either because it's a
+// directory or because it's been closed.
+func (f *File) check(op string) error {
+	if f.isdir() {
+		return &PathError{Op: op, Path: f.name, Code: ErrNotAFile}
+	}
+
+	if f.cfile == nil {
+		return &PathError{Op: op, Path: f.name, Code: ErrClosed}
+	}
+
+	return nil
 }
 
 // Close the file, release related resources. Returns an error, if any.
 func (f *File) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	if f.isdir() {
+		if f.dir.closed {
+			return &PathError{Op: "close", Path: f.name, Code: ErrClosed}
+		}
+		f.dir.closed = true
+		f.dir.names = nil
 		return nil
 	}
 
+	if f.cfile == nil {
+		return &PathError{Op: "close", Path: f.name, Code: ErrClosed}
+	}
+
+	for _, r := range f.readers {
+		C.PHYSFS_close(r)
+	}
+	f.readers = nil
+
 	if int(C.PHYSFS_close(f.cfile)) != 0 {
+		f.cfile = nil
+		untrackFile(f)
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("close", f.name)
 }
 
 // Read up to len(buf) bytes from the file into buf. Returns the number of bytes
 // read and an error, if any.
 func (f *File) Read(buf []byte) (n int, err error) {
-	if f.isdir() {
-		return 0, syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("read"); err != nil {
+		return 0, err
 	}
 
-	n = int(C.PHYSFS_read(f.cfile, unsafe.Pointer(&buf[0]), 1, C.PHYSFS_uint32(len(buf))))
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	n = int(C.PHYSFS_readBytes(f.cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(len(buf))))
 
 	if n == -1 {
-		err = errors.New(GetLastError())
+		return 0, lastError("read", f.name)
 	}
 
-	if f.EOF() {
+	if int(C.PHYSFS_eof(f.cfile)) != 0 {
 		err = io.EOF
 	}
 
@@ -119,23 +339,175 @@ func (f *File) Read(buf []byte) (n int, err error) {
 // Write the bytes in buf to the file. Returns the number of bytes written and
 // an error, if any.
 func (f *File) Write(buf []byte) (n int, err error) {
-	if f.isdir() {
-		return 0, syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("write"); err != nil {
+		return 0, err
+	}
+
+	if len(buf) == 0 {
+		return 0, nil
 	}
 
-	n = int(C.PHYSFS_write(f.cfile, unsafe.Pointer(&buf[0]), 1, C.PHYSFS_uint32(len(buf))))
+	n = int(C.PHYSFS_writeBytes(f.cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(len(buf))))
 
 	if n == -1 {
-		return n, errors.New(GetLastError())
+		return 0, lastError("write", f.name)
 	}
 
 	return n, nil
 }
 
+// Takes an idle handle for ReadAt from f, opening a new one if there are none.
+func (f *File) getReader() (*C.PHYSFS_File, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.check("readat"); err != nil {
+		return nil, err
+	}
+
+	if f.flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return nil, &PathError{Op: "readat", Path: f.name, Code: ErrOpenForWriting}
+	}
+
+	if n := len(f.readers); n > 0 {
+		r := f.readers[n-1]
+		f.readers = f.readers[:n-1]
+		return r, nil
+	}
+
+	cname := C.CString(f.name)
+	defer C.free(unsafe.Pointer(cname))
+	r := C.PHYSFS_openRead(cname)
+	if r == nil {
+		return nil, lastError("readat", f.name)
+	}
+
+	return r, nil
+}
+
+// Returns a handle taken by getReader to f, or closes it if f has been closed
+// in the meantime. If PhysicsFS has been deinitialized, the handle has already
+// been closed and is dropped.
+func (f *File) putReader(r *C.PHYSFS_File) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.deinit {
+		return
+	}
+	if f.cfile == nil {
+		C.PHYSFS_close(r)
+		return
+	}
+
+	f.readers = append(f.readers, r)
+}
+
+// Read len(buf) bytes from the file into buf, starting at offset off, in the
+// same way as os.File.ReadAt. The file's offset isn't changed, and ReadAt may be
+// called concurrently with itself and with the file's other methods, so File
+// implements io.ReaderAt for use with io.NewSectionReader, archive/zip, and so
+// on. ReadAt is only supported for files opened for reading. Each concurrent
+// call uses its own handle on the file, which is opened from the search path by
+// name and kept until the file is closed, so the search path shouldn't be
+// changed in a way that replaces the file while it's open. Returns the number
+// of bytes read and an error, if any. The error is io.EOF if the end of the
+// file was reached before buf was filled.
+func (f *File) ReadAt(buf []byte, off int64) (n int, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if off < 0 {
+		return 0, &PathError{Op: "readat", Path: f.name, Code: ErrInvalidArgument}
+	}
+
+	r, err := f.getReader()
+	if err != nil {
+		return 0, err
+	}
+	defer f.putReader(r)
+
+	if int(C.PHYSFS_seek(r, C.PHYSFS_uint64(off))) == 0 {
+		// Reading the error code clears it, so it can only be read once.
+		err := lastError("readat", f.name)
+		if errors.Is(err, ErrPastEOF) {
+			return 0, io.EOF
+		}
+		return 0, err
+	}
+
+	n = readFull(r, buf)
+	if n == -1 {
+		return 0, lastError("readat", f.name)
+	}
+	if n < len(buf) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Write the bytes in buf to the file starting at offset off, in the same way as
+// os.File.WriteAt. The file's offset isn't changed. PhysicsFS always writes to
+// the end of files that were opened for appending, so WriteAt returns an error
+// for them. Returns the number of bytes written and an error, if any.
+func (f *File) WriteAt(buf []byte, off int64) (n int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("writeat"); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case f.flag&(os.O_WRONLY|os.O_RDWR) == 0:
+		return 0, &PathError{Op: "writeat", Path: f.name, Code: ErrOpenForReading}
+	case f.flag&os.O_APPEND != 0:
+		return 0, &PathError{Op: "writeat", Path: f.name, Code: ErrUnsupported}
+	case off < 0:
+		return 0, &PathError{Op: "writeat", Path: f.name, Code: ErrInvalidArgument}
+	}
+
+	if len(buf) == 0 {
+		return 0, nil
+	}
+
+	cur := int64(C.PHYSFS_tell(f.cfile))
+	if cur == -1 {
+		return 0, lastError("writeat", f.name)
+	}
+	if int(C.PHYSFS_seek(f.cfile, C.PHYSFS_uint64(off))) == 0 {
+		return 0, lastError("writeat", f.name)
+	}
+
+	n = int(C.PHYSFS_writeBytes(f.cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(len(buf))))
+	if n == -1 {
+		n, err = 0, lastError("writeat", f.name)
+	} else if n < len(buf) {
+		err = lastError("writeat", f.name)
+	}
+
+	if (int(C.PHYSFS_seek(f.cfile, C.PHYSFS_uint64(cur))) == 0) && (err == nil) {
+		err = lastError("writeat", f.name)
+	}
+
+	return n, err
+}
+
 // Returns a boolean indicating whether or not the end of the file has been
 // reached.
 func (f *File) EOF() bool {
-	if f.isdir() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.check("eof") != nil {
 		return true
 	}
 
@@ -149,13 +521,18 @@ func (f *File) EOF() bool {
 // Returns a number indication the current position in the file, and an error,
 // if any.
 func (f *File) Tell() (int64, error) {
-	if f.isdir() {
-		return 0, syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("tell"); err != nil {
+		return 0, err
 	}
 
 	r := int64(C.PHYSFS_tell(f.cfile))
 	if r == -1 {
-		return r, errors.New(GetLastError())
+		return r, lastError("tell", f.name)
 	}
 
 	return r, nil
@@ -167,8 +544,13 @@ func (f *File) Tell() (int64, error) {
 // the file. Any other value will result in an error. Returns the new offset
 // and an error, if any.
 func (f *File) Seek(offset int64, whence int) (int64, error) {
-	if f.isdir() {
-		return 0, syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("seek"); err != nil {
+		return 0, err
 	}
 
 	newoff := offset
@@ -176,25 +558,25 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 	case 0:
 		break
 	case 1:
-		cp, err := f.Tell()
-		if err != nil {
-			return newoff + cp, err
+		cp := int64(C.PHYSFS_tell(f.cfile))
+		if cp == -1 {
+			return newoff + cp, lastError("seek", f.name)
 		}
 		newoff += cp
 	case 2:
-		eof, err := f.Length()
-		if err != nil {
-			return eof + newoff, err
+		eof := int64(C.PHYSFS_fileLength(f.cfile))
+		if eof == -1 {
+			return eof + newoff, lastError("seek", f.name)
 		}
 		newoff += eof
 	default:
-		return newoff, errors.New(fmt.Sprintf("Unknown value for whence: %v", whence))
+		return newoff, &PathError{Op: "seek", Path: f.name, Code: ErrInvalidArgument}
 	}
 
 	r := int64(C.PHYSFS_seek(f.cfile, C.PHYSFS_uint64(newoff)))
 
 	if r == 0 {
-		return newoff, errors.New(GetLastError())
+		return newoff, lastError("seek", f.name)
 	}
 
 	return newoff, nil
@@ -202,14 +584,19 @@ func (f *File) Seek(offset int64, whence int) (int64, error) {
 
 // Returns the total length of the file and an error, if any.
 func (f *File) Length() (int64, error) {
-	if f.isdir() {
-		return 0, syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("length"); err != nil {
+		return 0, err
 	}
 
 	r := int64(C.PHYSFS_fileLength(f.cfile))
 
 	if r == -1 {
-		return r, errors.New(GetLastError())
+		return r, lastError("length", f.name)
 	}
 
 	return r, nil
@@ -236,29 +623,40 @@ func (f *File) Length() (int64, error) {
 // when removing the buffer, not being able to allocate the buffer, and not
 // being able to flush the buffer to disk, among other unexpected problems.
 func (f *File) SetBuffer(size uint64) error {
-	if f.isdir() {
-		return syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("setbuffer"); err != nil {
+		return err
 	}
 
 	if int(C.PHYSFS_setBuffer(f.cfile, C.PHYSFS_uint64(size))) != 0 {
+		f.buffer = size
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("setbuffer", f.name)
 }
 
 // Flush the buffer of a buffered file. If the file was only opened for reading
 // or is unbuffered this will do nothing successfully. Returns an error, if any.
 func (f *File) Flush() error {
-	if f.isdir() {
-		return syscall.EISDIR
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := f.check("flush"); err != nil {
+		return err
 	}
 
 	if int(C.PHYSFS_flush(f.cfile)) != 0 {
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("flush", f.name)
 }
 
 // A synonym for File.Flush(). Exactly the same.
@@ -266,101 +664,63 @@ func (f *File) Sync() error {
 	return f.Flush()
 }
 
-// TODO: Make File.Stat() and File.Readdir() actually work correctly.
-
+// Returns an os.FileInfo describing the file. For files opened for reading the
+// information comes from the search path, as with Stat(), as long as the name
+// still refers to the same archive or directory that the file was opened
+// from. If it doesn't, because the search path has changed since, only the
+// size is known, and the times are left as the zero time.Time. For files
+// opened for writing the information comes from the write directory. Returns
+// an error, if any.
 func (f *File) Stat() (fi os.FileInfo, err error) {
-	size, err := f.Length()
-	if err == syscall.EISDIR {
-		return fileInfo{
-			f.name,
-			0,
-		}, nil
-	}
-	if err != nil {
-		return
-	}
-
-	return fileInfo{
-		f.name,
-		size,
-	}, nil
-}
-
-func (f *File) Readdir(count int) ([]os.FileInfo, error) {
-	if !f.isdir() {
-		return nil, syscall.ENOTDIR
-	}
-	if f.read < 0 {
-		return nil, syscall.EINVAL
-	}
-
-	files, err := EnumerateFiles(f.name)
-	if err != nil {
-		return nil, err
-	}
-
-	if count < 0 {
-		count = len(files)
-	}
-	if len(files)-f.read < count {
-		count = len(files) - f.read
+	if f.isdir() {
+		return Stat(f.name)
 	}
 
-	fi := make([]os.FileInfo, 0, count)
-	for i := range files[len(files)-count:] {
-		file, err := Open(path.Join(f.name, files[i]))
-		if err != nil {
-			return nil, err
-		}
-		info, err := file.Stat()
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		// The file's length is more reliable than the search path if the
+		// file has been replaced since it was opened.
+		size, err := f.Length()
 		if err != nil {
 			return nil, err
 		}
-		fi = append(fi, info)
-		file.Close()
-	}
 
-	f.read += count
-
-	return fi, nil
-}
-
-type fileInfo struct {
-	name string
-	size int64
-}
-
-func (fi fileInfo) Name() string {
-	return fi.name
-}
-
-func (fi fileInfo) Size() int64 {
-	return fi.size
-}
+		info, err := Stat(f.name)
+		if (err == nil) && (info.(*fileInfo).sys.RealDir == f.source) {
+			info.(*fileInfo).sys.Size = size
+			return info, nil
+		}
 
-func (fi fileInfo) Mode() os.FileMode {
-	if fi.IsDir() {
-		return os.ModeDir | 0755
+		// The name now refers to something else, so none of its metadata
+		// applies to this file.
+		return &fileInfo{
+			name: path.Base("/" + f.name),
+			sys: &FileInfoSys{
+				StatInfo: StatInfo{
+					Size: size,
+					Type: FileTypeRegular,
+				},
+				RealDir: f.source,
+			},
+		}, nil
 	}
 
-	return 0644
-}
-
-func (fi fileInfo) ModTime() time.Time {
-	mt, err := GetLastModTime(fi.name)
+	dir := GetWriteDir()
+	osfi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f.name)))
 	if err != nil {
-		return time.Time{}
+		return nil, err
 	}
 
-	return mt
-}
-
-func (fi fileInfo) IsDir() bool {
-	return IsDirectory(fi.name)
-}
-
-func (fi fileInfo) Sys() interface{} {
-	return nil
+	return &fileInfo{
+		name: osfi.Name(),
+		sys: &FileInfoSys{
+			StatInfo: StatInfo{
+				Size:    osfi.Size(),
+				ModTime: osfi.ModTime(),
+				Type:    FileTypeRegular,
+			},
+			RealDir: dir,
+		},
+	}, nil
 }
 
 type fileSystem struct{}
@@ -374,8 +734,12 @@ func FileSystem() http.FileSystem {
 
 func (fs *fileSystem) Open(name string) (http.File, error) {
 	fi, err := Open(name)
-	if err != nil && err.Error() == "File not found" {
-		err = syscall.ENOENT
+	if err != nil {
+		// os.IsNotExist() doesn't understand wrapped errors.
+		if errors.Is(err, os.ErrNotExist) {
+			err = syscall.ENOENT
+		}
+		return nil, err
 	}
-	return fi, err
+	return fi, nil
 }
@@ -1,8 +1,12 @@
 package physfs
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -87,3 +91,312 @@ func TestFile(t *testing.T) {
 		t.Fatalf("Error: %v\n", err.Error())
 	}
 }
+
+func TestOpenFile(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dir := t.TempDir()
+	err := SetWriteDir(dir)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(dir, "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	_, err = OpenFile("new", os.O_WRONLY)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v\n", err)
+	}
+
+	file, err := OpenFile("new", os.O_WRONLY|os.O_CREATE|os.O_EXCL, WithBuffer(64))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	fmt.Fprint(file, "first")
+	file.Close()
+
+	_, err = OpenFile("new", os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if !errors.Is(err, os.ErrExist) {
+		t.Fatalf("Expected ErrExist, got %v\n", err)
+	}
+	_, err = OpenFile("new", os.O_WRONLY|os.O_CREATE)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Expected ErrUnsupported, got %v\n", err)
+	}
+	_, err = OpenFile("new", os.O_RDWR)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Expected ErrUnsupported, got %v\n", err)
+	}
+	_, err = OpenFile("new", os.O_RDONLY|os.O_TRUNC)
+	if !errors.Is(err, os.ErrInvalid) {
+		t.Fatalf("Expected ErrInvalid, got %v\n", err)
+	}
+	_, err = OpenFile("new", os.O_RDONLY|os.O_CREATE|os.O_EXCL)
+	if !errors.Is(err, os.ErrExist) {
+		t.Fatalf("Expected ErrExist, got %v\n", err)
+	}
+
+	file, err = OpenFile("created", os.O_RDONLY|os.O_CREATE)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	file.Close()
+	if info, err := os.Stat(filepath.Join(dir, "created")); (err != nil) || (info.Size() != 0) {
+		t.Fatalf("Expected empty file to be created: %v\n", err)
+	}
+
+	file, err = OpenFile("new", os.O_APPEND|os.O_WRONLY)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	fmt.Fprint(file, ", second")
+	file.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "new"))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if string(data) != "first, second" {
+		t.Fatalf("Unexpected contents: %q\n", data)
+	}
+
+	file, err = OpenFile("new", os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	file.Close()
+
+	file, err = OpenFile("new", os.O_RDONLY)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf("Expected truncated file, got size %v\n", info.Size())
+	}
+}
+
+func TestOpenFileCreateOutsideSearchPath(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	// The write dir isn't in the search path, so the created file can't be
+	// opened for reading.
+	dir := t.TempDir()
+	err := SetWriteDir(dir)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "kept"), []byte("kept"), 0644)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	_, err = OpenFile("created", os.O_RDONLY|os.O_CREATE)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v\n", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "created")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected created file to be removed: %v\n", err)
+	}
+
+	_, err = OpenFile("kept", os.O_RDONLY|os.O_CREATE)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v\n", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "kept")); (err != nil) || (string(data) != "kept") {
+		t.Fatalf("Expected existing file to be left alone: %q, %v\n", data, err)
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dir := t.TempDir()
+	err := SetWriteDir(dir)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(dir, "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err := Create("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	fmt.Fprint(file, "0123456789")
+	n, err := file.WriteAt([]byte("abc"), 2)
+	if (err != nil) || (n != 3) {
+		t.Fatalf("Error: %v, %v\n", n, err)
+	}
+	fmt.Fprint(file, "!")
+	file.Close()
+
+	file, err = Open("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, 4)
+	_, err = file.Read(buf[:1])
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			buf := make([]byte, 4)
+			n, err := file.ReadAt(buf, 1)
+			if (err != nil) || (string(buf[:n]) != "1abc") {
+				t.Errorf("Unexpected result: %q, %v\n", buf[:n], err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err = file.ReadAt(buf, 8)
+	if (err != io.EOF) || (string(buf[:n]) != "89!") {
+		t.Fatalf("Unexpected result: %q, %v\n", buf[:n], err)
+	}
+
+	n, err = file.Read(buf[:1])
+	if (err != nil) || (string(buf[:n]) != "1") {
+		t.Fatalf("Offset was disturbed: %q, %v\n", buf[:n], err)
+	}
+
+	_, err = file.WriteAt(buf, 0)
+	if !errors.Is(err, ErrOpenForReading) {
+		t.Fatalf("Expected ErrOpenForReading, got %v\n", err)
+	}
+}
+
+func TestFileTypeCached(t *testing.T) {
+	dir := setupWriteDir(t)
+	defer Deinit()
+
+	err := WriteFile("name", []byte("data"))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err := Open("name")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	// Something else now provides name as a directory, but the file that was
+	// already opened should keep acting like a file.
+	err = os.MkdirAll(filepath.Join(dir, "other", "name"), 0755)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(filepath.Join(dir, "other"), "", false)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	buf := make([]byte, 4)
+	n, err := file.Read(buf)
+	if ((err != nil) && (err != io.EOF)) || (string(buf[:n]) != "data") {
+		t.Fatalf("Unexpected result: %q, %v\n", buf[:n], err)
+	}
+
+	dirFile, err := Open("name")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	_, err = dirFile.Read(buf)
+	if !errors.Is(err, ErrNotAFile) {
+		t.Fatalf("Expected ErrNotAFile, got %v\n", err)
+	}
+	err = dirFile.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = dirFile.Close()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v\n", err)
+	}
+}
+
+func benchmarkSmall(b *testing.B, op func(*File) error) {
+	setupBenchmark(b, 1<<20)
+	defer Deinit()
+
+	file, err := Open("bench")
+	if err != nil {
+		b.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	b.SetBytes(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := op(file); err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+	}
+}
+
+func BenchmarkSmallRead(b *testing.B) {
+	buf := make([]byte, 16)
+	benchmarkSmall(b, func(file *File) error {
+		_, err := file.Read(buf)
+		if err == io.EOF {
+			_, err = file.Seek(0, io.SeekStart)
+		}
+		return err
+	})
+}
+
+func BenchmarkTell(b *testing.B) {
+	benchmarkSmall(b, func(file *File) error {
+		_, err := file.Tell()
+		return err
+	})
+}
+
+func BenchmarkSeek(b *testing.B) {
+	benchmarkSmall(b, func(file *File) error {
+		_, err := file.Seek(16, io.SeekStart)
+		return err
+	})
+}
+
+func BenchmarkEOF(b *testing.B) {
+	benchmarkSmall(b, func(file *File) error {
+		file.EOF()
+		return nil
+	})
+}
@@ -0,0 +1,229 @@
+package physfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"syscall"
+)
+
+// searchPathFS implements fs.FS, and the optional interfaces in io/fs, on top
+// of the PhysicsFS search path. dir is the PhysicsFS path that acts as the root
+// of the file system, with "" being the root of the search path itself.
+type searchPathFS struct {
+	dir string
+}
+
+// Returns an fs.FS that provides read-only access to the current search path.
+// The returned value also implements fs.ReadDirFS, fs.ReadFileFS, fs.StatFS,
+// fs.GlobFS and fs.SubFS, so it can be handed directly to functions such as
+// fs.WalkDir, http.FS and template.ParseFS. Changes to the search path are
+// reflected immediately.
+func FS() fs.FS {
+	return searchPathFS{}
+}
+
+// Converts name, which must be a valid io/fs path, to a PhysicsFS path.
+func (fsys searchPathFS) resolve(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return fsys.dir, nil
+	}
+
+	return path.Join(fsys.dir, name), nil
+}
+
+func (fsys searchPathFS) Open(name string) (fs.File, error) {
+	full, err := fsys.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := statFS(full)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+
+	if info.IsDir() {
+		return &fsDir{
+			info: info,
+			path: full,
+		}, nil
+	}
+
+	f, err := Open(full)
+	if err != nil {
+		return nil, fsError("open", name, err)
+	}
+
+	return f, nil
+}
+
+func (fsys searchPathFS) Stat(name string) (fs.FileInfo, error) {
+	full, err := fsys.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := statFS(full)
+	if err != nil {
+		return nil, fsError("stat", name, err)
+	}
+
+	return info, nil
+}
+
+func (fsys searchPathFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	full, err := fsys.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ReadDir(full)
+	if err != nil {
+		return nil, fsError("readdir", name, err)
+	}
+
+	return entries, nil
+}
+
+func (fsys searchPathFS) ReadFile(name string) ([]byte, error) {
+	full, err := fsys.resolve("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := statFS(full)
+	if err != nil {
+		return nil, fsError("readfile", name, err)
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: syscall.EISDIR}
+	}
+
+	buf, err := ReadFile(full)
+	if err != nil {
+		return nil, fsError("readfile", name, err)
+	}
+
+	return buf, nil
+}
+
+func (fsys searchPathFS) Glob(pattern string) ([]string, error) {
+	// Hide the Glob method so that fs.Glob falls back to its ReadDir based
+	// implementation instead of calling back into this one.
+	return fs.Glob(struct{ fs.ReadDirFS }{fsys}, pattern)
+}
+
+func (fsys searchPathFS) Sub(dir string) (fs.FS, error) {
+	full, err := fsys.resolve("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if full != "" && !IsDirectory(full) {
+		return nil, &fs.PathError{Op: "sub", Path: dir, Err: fs.ErrNotExist}
+	}
+
+	return searchPathFS{full}, nil
+}
+
+// Wraps err, as returned by the rest of the package, in an *fs.PathError
+// reporting name rather than the PhysicsFS path.
+func fsError(op, name string, err error) error {
+	var pe *PathError
+	if errors.As(err, &pe) {
+		err = pe.Code
+	}
+
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Gathers information about the PhysicsFS path name for use with io/fs.
+func statFS(name string) (fs.FileInfo, error) {
+	info, err := Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return rootInfo{info}, nil
+	}
+
+	return info, nil
+}
+
+// rootInfo renames the root of the search path to ".", as io/fs expects.
+type rootInfo struct {
+	fs.FileInfo
+}
+
+func (rootInfo) Name() string {
+	return "."
+}
+
+// fsDir is a directory opened through the io/fs implementation. It implements
+// fs.ReadDirFile.
+type fsDir struct {
+	info fs.FileInfo
+	path string
+
+	entries []fs.DirEntry
+	read    bool
+	closed  bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *fsDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: syscall.EISDIR}
+}
+
+func (d *fsDir) Close() error {
+	if d.closed {
+		return &fs.PathError{Op: "close", Path: d.path, Err: fs.ErrClosed}
+	}
+
+	d.closed = true
+	return nil
+}
+
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: d.path, Err: fs.ErrClosed}
+	}
+
+	if !d.read {
+		entries, err := ReadDir(d.path)
+		if err != nil {
+			return nil, fsError("readdir", d.path, err)
+		}
+
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
@@ -0,0 +1,42 @@
+package physfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/a.zip", "a", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	fsys := FS()
+	err = fstest.TestFS(fsys,
+		"dir1/file1",
+		"a/index.html",
+		"a/hello-world.go",
+	)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	_, err = fs.Stat(fsys, "does/not/exist")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected fs.ErrNotExist, got %v\n", err)
+	}
+}
@@ -0,0 +1,195 @@
+package physfs
+
+import (
+	"io"
+	"runtime"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+// readerAtIo is the Go side of a PHYSFS_Io created by MountReaderAt. Every
+// PHYSFS_Io, including duplicates, has its own readerAtIo so that each one
+// keeps track of its own position.
+type readerAtIo struct {
+	r    io.ReaderAt
+	size int64
+	pos  int64
+}
+
+// Implemented by readers passed to mountReaderAt that need to know when
+// PhysicsFS is using them, such as to only keep a file open while it is.
+// acquire is called for every PHYSFS_Io, including duplicates, that reads from
+// the reader, and release once it's destroyed.
+type ioUser interface {
+	acquire() error
+	release()
+}
+
+// Allocates a PHYSFS_Io backed by ra. Returns nil if the allocation fails.
+func newReaderAtIo(ra *readerAtIo) *C.PHYSFS_Io {
+	if u, ok := ra.r.(ioUser); ok {
+		if err := u.acquire(); err != nil {
+			C.PHYSFS_setErrorCode(C.PHYSFS_ERR_IO)
+			return nil
+		}
+	}
+
+	h := cgo.NewHandle(ra)
+	cio := C.newReaderAtIo(C.uintptr_t(h))
+	if cio == nil {
+		h.Delete()
+		if u, ok := ra.r.(ioUser); ok {
+			u.release()
+		}
+	}
+
+	return cio
+}
+
+func getReaderAtIo(cio *C.PHYSFS_Io) *readerAtIo {
+	return cgo.Handle(uintptr(cio.opaque)).Value().(*readerAtIo)
+}
+
+//export readerAtIoRead
+func readerAtIoRead(cio *C.PHYSFS_Io, buf unsafe.Pointer, n C.PHYSFS_uint64) (r C.PHYSFS_sint64) {
+	defer recoverAppCallback(func() { r = -1 })
+
+	ra := getReaderAtIo(cio)
+
+	remaining := ra.size - ra.pos
+	if remaining <= 0 {
+		return 0
+	}
+	if uint64(n) > uint64(remaining) {
+		n = C.PHYSFS_uint64(remaining)
+	}
+
+	read, err := ra.r.ReadAt(unsafe.Slice((*byte)(buf), int(n)), ra.pos)
+	ra.pos += int64(read)
+	if (err != nil) && (err != io.EOF) && (read == 0) {
+		C.PHYSFS_setErrorCode(C.PHYSFS_ERR_IO)
+		return -1
+	}
+
+	return C.PHYSFS_sint64(read)
+}
+
+//export readerAtIoSeek
+func readerAtIoSeek(cio *C.PHYSFS_Io, off C.PHYSFS_uint64) C.int {
+	ra := getReaderAtIo(cio)
+
+	if uint64(off) > uint64(ra.size) {
+		C.PHYSFS_setErrorCode(C.PHYSFS_ERR_PAST_EOF)
+		return 0
+	}
+
+	ra.pos = int64(off)
+	return 1
+}
+
+//export readerAtIoTell
+func readerAtIoTell(cio *C.PHYSFS_Io) C.PHYSFS_sint64 {
+	return C.PHYSFS_sint64(getReaderAtIo(cio).pos)
+}
+
+//export readerAtIoLength
+func readerAtIoLength(cio *C.PHYSFS_Io) C.PHYSFS_sint64 {
+	return C.PHYSFS_sint64(getReaderAtIo(cio).size)
+}
+
+//export readerAtIoDuplicate
+func readerAtIoDuplicate(cio *C.PHYSFS_Io) *C.PHYSFS_Io {
+	ra := getReaderAtIo(cio)
+
+	// PhysicsFS expects duplicates to start at the beginning of the stream.
+	return newReaderAtIo(&readerAtIo{
+		r:    ra.r,
+		size: ra.size,
+	})
+}
+
+//export readerAtIoFlush
+func readerAtIoFlush(cio *C.PHYSFS_Io) C.int {
+	return 1
+}
+
+//export readerAtIoDestroy
+func readerAtIoDestroy(cio *C.PHYSFS_Io) {
+	h := cgo.Handle(uintptr(cio.opaque))
+	if u, ok := h.Value().(*readerAtIo).r.(ioUser); ok {
+		u.release()
+	}
+
+	h.Delete()
+	C.free(unsafe.Pointer(cio))
+}
+
+// Adds the archive that can be read from r to the search path, mounting it at
+// mp in the same way as Mount. size is the length of the archive in bytes.
+// name is used to identify the archive, such as when it is passed to
+// RemoveFromSearchPath, and its extension helps PhysicsFS guess the type of
+// archive; it does not need to exist anywhere.
+//
+// PhysicsFS may read from r at any time until the archive is removed from the
+// search path or PhysicsFS is deinitialized, potentially from several open
+// files at once, so r must support concurrent calls to ReadAt, as is required
+// by io.ReaderAt. As with Mount, mounting something under a name that is
+// already in the search path does nothing. Returns an error, if any.
+func MountReaderAt(r io.ReaderAt, size int64, name, mp string, app bool) error {
+	return mountReaderAt(nil, r, size, name, mp, app)
+}
+
+// Mounts r as part of b.
+func mountReaderAt(b *batch, r io.ReaderAt, size int64, name, mp string, app bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	mountLock.Lock()
+	defer mountLock.Unlock()
+
+	if _, err := GetMountPoint(name); err == nil {
+		return nil
+	}
+
+	if size < 0 {
+		return &PathError{Op: "mountreaderat", Path: name, Code: ErrInvalidArgument}
+	}
+
+	a := 0
+	if app {
+		a = 1
+	}
+
+	cio := newReaderAtIo(&readerAtIo{
+		r:    r,
+		size: size,
+	})
+	if cio == nil {
+		return lastError("mountreaderat", name)
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cmp := C.CString(mp)
+	defer C.free(unsafe.Pointer(cmp))
+
+	if int(C.PHYSFS_mountIo(cio, cname, cmp, C.int(a))) != 0 {
+		recordMount(name, sourceKind{head: readHead(r)}, func(b *batch, mp string, app bool) error {
+			return mountReaderAt(b, r, size, name, mp, app)
+		})
+		notifyMount(b, name)
+		return nil
+	}
+
+	// PhysicsFS leaves the PHYSFS_Io alone if the mount fails.
+	err := lastError("mountreaderat", name)
+	readerAtIoDestroy(cio)
+
+	return err
+}
@@ -0,0 +1,64 @@
+package physfs
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMountReaderAt(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	archive, err := os.Open("../test/a.zip")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer archive.Close()
+	info, err := archive.Stat()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	// Go through something other than an *os.File.
+	r := io.NewSectionReader(archive, 0, info.Size())
+	err = MountReaderAt(r, info.Size(), "a.zip", "ra", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	// Open two files at once to make sure duplicated streams keep their own
+	// positions.
+	file1, err := Open("ra/index.html")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file1.Close()
+	file2, err := Open("ra/hello-world.go")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file2.Close()
+
+	buf1, err := io.ReadAll(file1)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	buf2, err := io.ReadAll(file2)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (len(buf1) != 5) || (len(buf2) != 538) {
+		t.Fatalf("Unexpected lengths: %v, %v\n", len(buf1), len(buf2))
+	}
+
+	err = MountReaderAt(r, info.Size()/2, "broken.zip", "", true)
+	if err == nil {
+		t.Fatalf("Expected error mounting truncated archive.\n")
+	}
+}
@@ -0,0 +1,133 @@
+package physfs
+
+import (
+	"iter"
+	"runtime"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+type contextEnumerateCallback struct {
+	cb func(string, string) bool
+
+	panicked   bool
+	panicValue interface{}
+}
+
+//export wrapEnumerateCallback
+func wrapEnumerateCallback(data C.uintptr_t, origdir *C.char, fname *C.char) (r C.PHYSFS_EnumerateCallbackResult) {
+	cec := cgo.Handle(data).Value().(*contextEnumerateCallback)
+	defer func() {
+		if v := recover(); v != nil {
+			cec.panicked = true
+			cec.panicValue = v
+			r = C.PHYSFS_ENUM_STOP
+		}
+	}()
+
+	if !cec.cb(C.GoString(origdir), C.GoString(fname)) {
+		return C.PHYSFS_ENUM_STOP
+	}
+
+	return C.PHYSFS_ENUM_OK
+}
+
+// Calls cb for each entry in dir, with the same arguments as an
+// EnumFilesCallback, until it returns false, at which point PhysicsFS stops
+// enumerating. A panic in cb is carried across PhysicsFS and resumed once it
+// has returned.
+func enumerate(dir string, cb func(origdir, fname string) bool) error {
+	// PhysicsFS holds its lock while calling cb, which it only lets the same
+	// thread take again.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cdir := C.CString(dir)
+	defer C.free(unsafe.Pointer(cdir))
+
+	cec := &contextEnumerateCallback{
+		cb: cb,
+	}
+	h := cgo.NewHandle(cec)
+	defer h.Delete()
+
+	r := C.enumerate(cdir, C.uintptr_t(h))
+	if cec.panicked {
+		panic(cec.panicValue)
+	}
+	if int(r) == 0 {
+		return lastError("enumerate", dir)
+	}
+
+	return nil
+}
+
+// Returns an iterator over the names of the files and directories in dir in
+// the search path, in no particular order. Each name is yielded once, with a
+// nil error, even if several archives or directories in the search path
+// provide it. If the enumeration fails, a final empty name and the error are
+// yielded. Breaking out of the loop stops PhysicsFS from enumerating the rest
+// of the directory.
+//
+// PhysicsFS holds an internal lock while running the body of the loop. The
+// body may call other functions in this package, but must not wait for other
+// goroutines that do.
+func Entries(dir string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		seen := make(map[string]struct{})
+		stopped := false
+		err := enumerate(dir, func(origdir, fname string) bool {
+			if _, ok := seen[fname]; ok {
+				return true
+			}
+			seen[fname] = struct{}{}
+
+			if !yield(fname, nil) {
+				stopped = true
+				return false
+			}
+
+			return true
+		})
+		if (err != nil) && !stopped {
+			yield("", err)
+		}
+	}
+}
+
+// Returns an iterator over the search path, in order. The body of the loop
+// runs under the same restrictions as for Entries.
+func SearchPathSeq() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		stringSeq(yield, GetSearchPathCallback[struct{}])
+	}
+}
+
+// Returns an iterator over the detected CD-ROM directories. The body of the
+// loop runs under the same restrictions as for Entries.
+func CdRomDirsSeq() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		stringSeq(yield, GetCdRomDirsCallback[struct{}])
+	}
+}
+
+// Passes each string given to a StringCallback by f to yield until it returns
+// false. f can't be stopped early, so anything after that is ignored.
+func stringSeq(yield func(string) bool, f func(StringCallback[struct{}], struct{}) error) {
+	stopped := false
+	err := f(func(_ struct{}, str string) {
+		if !stopped && !yield(str) {
+			stopped = true
+		}
+	}, struct{}{})
+
+	if pe, ok := err.(*PanicError); ok {
+		panic(pe.Value)
+	}
+}
@@ -0,0 +1,62 @@
+package physfs
+
+import (
+	"testing"
+)
+
+func TestEntries(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/a.zip", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	var names []string
+	for name, err := range Entries("") {
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		names = append(names, name)
+	}
+	if len(names) != 4 {
+		t.Fatalf("Unexpected entries: %v\n", names)
+	}
+
+	count := 0
+	for _, err := range Entries("") {
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		count++
+		break
+	}
+	if count != 1 {
+		t.Fatalf("Loop continued after break.\n")
+	}
+
+	for name, err := range Entries("does/not/exist") {
+		if err == nil {
+			t.Fatalf("Unexpected entry: %v\n", name)
+		}
+	}
+
+	var sp []string
+	for dir := range SearchPathSeq() {
+		sp = append(sp, dir)
+		break
+	}
+	if (len(sp) != 1) || (sp[0] != "../test/a.zip") {
+		t.Fatalf("Unexpected search path: %v\n", sp)
+	}
+}
@@ -0,0 +1,362 @@
+package physfs
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+// Held while mounting things that PhysicsFS takes ownership of, as it silently
+// ignores them if something with the same name is mounted in the meantime.
+var mountLock sync.Mutex
+
+var (
+	memoryMountsLock sync.Mutex
+
+	// Pinners for the buffers currently mounted by MountMemory, keyed by the
+	// address of the first byte. The same buffer may be mounted more than once
+	// under different names, so each address can have several pinners.
+	memoryMounts = make(map[unsafe.Pointer][]*runtime.Pinner)
+)
+
+func pinMemoryMount(p unsafe.Pointer) {
+	var pinner runtime.Pinner
+	pinner.Pin(p)
+
+	memoryMountsLock.Lock()
+	defer memoryMountsLock.Unlock()
+	memoryMounts[p] = append(memoryMounts[p], &pinner)
+}
+
+//export releaseMemoryMount
+func releaseMemoryMount(p unsafe.Pointer) {
+	memoryMountsLock.Lock()
+	defer memoryMountsLock.Unlock()
+
+	pinners := memoryMounts[p]
+	if len(pinners) == 0 {
+		return
+	}
+
+	pinners[len(pinners)-1].Unpin()
+	pinners = pinners[:len(pinners)-1]
+	if len(pinners) == 0 {
+		delete(memoryMounts, p)
+		return
+	}
+	memoryMounts[p] = pinners
+}
+
+// Adds the archive contained in data to the search path, mounting it at mp in
+// the same way as Mount. name is used to identify the archive, such as when it
+// is passed to RemoveFromSearchPath, and its extension helps PhysicsFS guess the
+// type of archive; it does not need to exist anywhere. data can be in any of
+// the formats returned by SupportedArchiveTypes().
+//
+// data is used directly, rather than copied, and is kept alive until the
+// archive is removed from the search path or PhysicsFS is deinitialized, so it
+// must not be modified while it is mounted. As with Mount, mounting something
+// under a name that is already in the search path does nothing. Returns an
+// error, if any.
+func MountMemory(data []byte, name, mp string, app bool) error {
+	return mountMemory(nil, data, name, mp, app)
+}
+
+// Mounts data as part of b.
+func mountMemory(b *batch, data []byte, name, mp string, app bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	mountLock.Lock()
+	defer mountLock.Unlock()
+
+	if _, err := GetMountPoint(name); err == nil {
+		return nil
+	}
+
+	a := 0
+	if app {
+		a = 1
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cmp := C.CString(mp)
+	defer C.free(unsafe.Pointer(cmp))
+
+	// PhysicsFS rejects a nil buffer with a useful error, so let it handle empty
+	// slices instead of special-casing them here.
+	var buf unsafe.Pointer
+	if len(data) > 0 {
+		buf = unsafe.Pointer(&data[0])
+		pinMemoryMount(buf)
+	}
+
+	if int(C.mountMemory(buf, C.PHYSFS_uint64(len(data)), cname, cmp, C.int(a))) != 0 {
+		recordMount(name, sourceKind{head: data[:min(len(data), headSize)]}, func(b *batch, mp string, app bool) error {
+			return mountMemory(b, data, name, mp, app)
+		})
+		notifyMount(b, name)
+		return nil
+	}
+
+	// PhysicsFS doesn't call the release callback if the mount fails.
+	err := lastError("mountmemory", name)
+	if buf != nil {
+		releaseMemoryMount(buf)
+	}
+
+	return err
+}
+
+// Adds the archive contained in the file f, which must be open for reading, to
+// the search path, mounting it at mp in the same way as Mount. This allows
+// archives inside of other archives to be mounted. name is used to identify
+// the archive in the same way as with MountMemory.
+//
+// If the mount succeeds, PhysicsFS takes ownership of f's handle, closing it
+// when the archive is removed from the search path, so f is closed and can no
+// longer be used. If it fails, f is left open. As with Mount, mounting
+// something under a name that is already in the search path does nothing, and
+// also leaves f open. Returns an error, if any.
+func MountFile(f *File, name, mp string, app bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	mountLock.Lock()
+	defer mountLock.Unlock()
+
+	// Read before f is locked, as ReadAt locks it itself.
+	head := readHead(f)
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.check("mountfile"); err != nil {
+		return err
+	}
+	if !f.readOnly() {
+		return &PathError{Op: "mountfile", Path: f.name, Code: ErrOpenForWriting}
+	}
+
+	if _, err := GetMountPoint(name); err == nil {
+		return nil
+	}
+
+	a := 0
+	if app {
+		a = 1
+	}
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cmp := C.CString(mp)
+	defer C.free(unsafe.Pointer(cmp))
+
+	if int(C.PHYSFS_mountHandle(f.cfile, cname, cmp, C.int(a))) == 0 {
+		return lastError("mountfile", name)
+	}
+
+	for _, r := range f.readers {
+		C.PHYSFS_close(r)
+	}
+	f.readers = nil
+	f.cfile = nil
+	untrackFile(f)
+
+	// The handle is closed when the archive is unmounted, so it can't be
+	// mounted again.
+	recordMount(name, sourceKind{head: head}, nil)
+	notifyMount(nil, name)
+
+	return nil
+}
+
+type mountOptions struct {
+	root     string
+	autoRoot bool
+}
+
+// An option that changes how Mount and OpenMount mount an archive or
+// directory.
+type MountOption func(*mountOptions)
+
+// Returns a MountOption that treats subdir, a directory inside the archive or
+// directory being mounted, as its root, so that only what is inside subdir is
+// visible, at the mount point. This is useful for archives that wrap
+// everything in a single top-level directory.
+//
+// Archives use SetRoot with PhysicsFS 3.1 or newer. With older versions, ZIP
+// archives are mounted through a copy of their directory with subdir removed
+// from the names, which needs PhysicsFS 2.1 or newer, and Mount fails with
+// ErrUnsupported for other types of archive. Native directories work with any
+// version, as subdir is mounted directly instead, in which case it's what
+// appears in the search path.
+//
+// As the root can't be changed without disturbing what is already mounted,
+// mounting something that is already in the search path with this option
+// fails with ErrDuplicate.
+func WithRoot(subdir string) MountOption {
+	return func(o *mountOptions) {
+		o.root = subdir
+	}
+}
+
+// Returns a MountOption that detects an archive or directory that contains
+// nothing but a single directory, and treats that directory as the root, as
+// with WithRoot. Anything else is mounted as usual. Native directories and
+// ZIP archives are looked inside directly. PhysicsFS can only look inside
+// other archives once they're mounted, so they're briefly mounted at a mount
+// point of their own, "/.physfs-root-probe-" followed by a number, first. While
+// they are, they're visible to GetSearchPath and to lookups in the search
+// path, but not to Mounts, Snapshot or the functions that rearrange the search
+// path, which wait for them to be removed again, and they aren't announced to
+// OnChange subscribers.
+func WithAutoRoot() MountOption {
+	return func(o *mountOptions) {
+		o.autoRoot = true
+	}
+}
+
+var rootProbes uint64
+
+// Returns the name of the only entry in the archive or directory dir, if it's
+// a directory, or "" otherwise. zr is dir opened as a ZIP archive, if it is
+// one. The caller must hold orderLock, so that anything else that holds it
+// doesn't see the probe that other archives are mounted as.
+func wrapperDir(dir string, native bool, zr *zip.Reader) (string, error) {
+	switch {
+	case native:
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", err
+		}
+		if (len(entries) != 1) || !entries[0].IsDir() {
+			return "", nil
+		}
+		return entries[0].Name(), nil
+	case zr != nil:
+		return zipWrapperDir(zr), nil
+	}
+
+	mountLock.Lock()
+	rootProbes++
+	mp := fmt.Sprintf("/.physfs-root-probe-%v", rootProbes)
+	mountLock.Unlock()
+
+	quiet := quietBatch()
+	if err := mountSource(quiet, dir, mp, true); err != nil {
+		return "", err
+	}
+
+	var root string
+	names, err := EnumerateFiles(mp)
+	if (err == nil) && (len(names) == 1) && IsDirectory(path.Join(mp, names[0])) {
+		root = names[0]
+	}
+
+	// If the probe can't be removed, mounting dir properly would do nothing.
+	if err := unmount(quiet, dir); err != nil {
+		return "", err
+	}
+
+	return root, err
+}
+
+// Mounts dir with opts, as part of b, and returns the name that it was mounted
+// under. The caller must hold orderLock.
+func mount(b *batch, dir, mp string, app bool, opts []MountOption) (string, error) {
+	var o mountOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	root := strings.Trim(o.root, "/")
+	if (root == "") && !o.autoRoot {
+		return dir, mountSource(b, dir, mp, app)
+	}
+
+	if _, err := GetMountPoint(dir); err == nil {
+		return "", &PathError{Op: "mount", Path: dir, Code: ErrDuplicate}
+	}
+
+	fi, err := os.Stat(dir)
+	native := (err == nil) && fi.IsDir()
+
+	// ZIP archives on disk can be looked inside, and have their root changed
+	// if need be, without PhysicsFS.
+	var zr *zip.Reader
+	var file *os.File
+	if (err == nil) && !native {
+		zr, file, err = openZip(dir)
+		if err != nil {
+			return "", err
+		}
+	}
+	if file != nil {
+		defer file.Close()
+	}
+
+	if root == "" {
+		root, err = wrapperDir(dir, native, zr)
+		if err != nil {
+			return "", err
+		}
+		if root == "" {
+			return dir, mountSource(b, dir, mp, app)
+		}
+	}
+
+	if !Capabilities().SetRoot {
+		switch {
+		case native:
+			source := filepath.Join(dir, filepath.FromSlash(root))
+			if _, err := GetMountPoint(source); err == nil {
+				return "", &PathError{Op: "mount", Path: source, Code: ErrDuplicate}
+			}
+			return source, mountSource(b, source, mp, app)
+		case zr != nil:
+			return dir, mountZipRoot(b, dir, zr, root, mp, app)
+		}
+
+		return "", &PathError{Op: "mount", Path: dir, Code: ErrUnsupported}
+	}
+
+	return dir, mountWithRoot(b, dir, root, mp, app)
+}
+
+// Mounts dir with root as its root, using SetRoot, as part of b. Nothing else
+// can mount dir before its root is set, and it's announced once, as a single
+// EventMount, when it is.
+func mountWithRoot(b *batch, dir, root, mp string, app bool) error {
+	mountLock.Lock()
+	defer mountLock.Unlock()
+
+	if _, err := GetMountPoint(dir); err == nil {
+		return &PathError{Op: "mount", Path: dir, Code: ErrDuplicate}
+	}
+
+	quiet := quietBatch()
+	if err := mountSourceLocked(quiet, dir, mp, app); err != nil {
+		return err
+	}
+	if err := setRoot(quiet, dir, root); err != nil {
+		return errors.Join(err, unmount(quiet, dir))
+	}
+
+	notifyMount(b, dir)
+	return nil
+}
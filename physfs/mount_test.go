@@ -0,0 +1,195 @@
+package physfs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMountMemory(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	data, err := os.ReadFile("../test/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	err = MountMemory(data, "zip1.zip", "mem", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err := Open("mem/dir1/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	buf, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	file.Close()
+	if len(buf) != 16 {
+		t.Fatalf("Expected 16 bytes, got %v\n", len(buf))
+	}
+
+	err = RemoveFromSearchPath("zip1.zip")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if len(memoryMounts) != 0 {
+		t.Fatalf("Buffer still pinned after unmount.\n")
+	}
+
+	err = MountMemory(nil, "empty.zip", "", true)
+	if err == nil {
+		t.Fatalf("Expected error mounting empty buffer.\n")
+	}
+}
+
+func TestMountFile(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test", "outer", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err := Open("outer/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MountFile(file, "inner.zip", "inner", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = file.Close()
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v\n", err)
+	}
+
+	if !Exists("inner/dir1/file1") {
+		t.Fatalf("Nested archive not mounted.\n")
+	}
+
+	err = Unmount("inner.zip")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if Exists("inner/dir1/file1") {
+		t.Fatalf("Nested archive still mounted.\n")
+	}
+
+	err = Unmount("inner.zip")
+	if !errors.Is(err, ErrNotMounted) {
+		t.Fatalf("Expected ErrNotMounted, got %v\n", err)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	err = SetRoot("../test/zip1.aoi", "dir1")
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("PhysicsFS is older than 3.1.")
+	}
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	if !Exists("file1") || Exists("dir1") {
+		t.Fatalf("Root not changed.\n")
+	}
+
+	err = SetRoot("../test/zip1.aoi", "")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !Exists("dir1/file1") {
+		t.Fatalf("Root not restored.\n")
+	}
+}
+
+func TestMountRoot(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(dir, "MyMod-1.2", "maps"), 0755)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	m, err := OpenMount(dir, "mod", true, WithAutoRoot())
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !IsDirectory("mod/maps") {
+		t.Fatalf("Wrapper directory not used as root.\n")
+	}
+	err = m.Unmount()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	gen := Generation()
+	err = Mount("../test/zip1.aoi", "", true, WithAutoRoot())
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip("PhysicsFS is older than 2.1.")
+	}
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !Exists("file1") || Exists("dir1") {
+		t.Fatalf("Wrapper directory not used as root.\n")
+	}
+	sp, err := GetSearchPath()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if len(sp) != 1 {
+		t.Fatalf("Probe left in search path: %v\n", sp)
+	}
+	if Generation() != gen+1 {
+		t.Fatalf("Expected a single change, got %v\n", Generation()-gen)
+	}
+
+	err = Mount("../test/zip1.aoi", "", true, WithAutoRoot())
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate, got %v\n", err)
+	}
+
+	err = Mount("../test", "", true, WithRoot("missing"))
+	if err == nil {
+		t.Fatalf("Expected error for missing root.\n")
+	}
+}
@@ -0,0 +1,353 @@
+package physfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var openFiles struct {
+	sync.Mutex
+
+	files map[*File]struct{}
+
+	// Closed and replaced whenever a file is closed, to wake up anything
+	// waiting for files to be closed.
+	closed chan struct{}
+}
+
+// Records that f has been opened, so that it can be found by the mount that
+// it comes from.
+func trackFile(f *File) {
+	openFiles.Lock()
+	defer openFiles.Unlock()
+
+	if openFiles.files == nil {
+		openFiles.files = make(map[*File]struct{})
+	}
+	openFiles.files[f] = struct{}{}
+}
+
+// Records that f has been closed.
+func untrackFile(f *File) {
+	openFiles.Lock()
+	defer openFiles.Unlock()
+
+	delete(openFiles.files, f)
+	if openFiles.closed != nil {
+		close(openFiles.closed)
+		openFiles.closed = nil
+	}
+}
+
+// Returns the open files that come from source, and a channel that is closed
+// the next time any file is closed.
+func filesFrom(source string) ([]*File, <-chan struct{}) {
+	openFiles.Lock()
+	defer openFiles.Unlock()
+
+	var files []*File
+	for f := range openFiles.files {
+		if f.source == source {
+			files = append(files, f)
+		}
+	}
+
+	if openFiles.closed == nil {
+		openFiles.closed = make(chan struct{})
+	}
+
+	return files, openFiles.closed
+}
+
+// Marks every open file as closed after PhysicsFS has closed them itself, as
+// it does when it's deinitialized.
+func forgetOpenFiles() {
+	openFiles.Lock()
+	files := openFiles.files
+	openFiles.files = nil
+	if openFiles.closed != nil {
+		close(openFiles.closed)
+		openFiles.closed = nil
+	}
+	openFiles.Unlock()
+
+	for f := range files {
+		f.lock.Lock()
+		f.cfile = nil
+		f.readers = nil
+		f.deinit = true
+		f.lock.Unlock()
+	}
+}
+
+// A handle to an archive or directory in the search path, which remembers
+// what was mounted and where, and can remove it again.
+type MountHandle struct {
+	source     string
+	mountPoint string
+}
+
+// Mounts dir at mp in the same way as Mount, and returns a handle to it. The
+// handle's source may differ from dir if a root was chosen for a native
+// directory with opts. Returns the handle and an error, if any.
+func OpenMount(dir, mp string, app bool, opts ...MountOption) (*MountHandle, error) {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+
+	source, err := mount(nil, dir, mp, app, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetMount(source)
+}
+
+// Returns a handle to source, which must already be in the search path. This
+// can be used with anything that was mounted, including by MountMemory,
+// MountReaderAt and MountFile, by passing the name that it was mounted under.
+// Returns the handle and an error, if any.
+func GetMount(source string) (*MountHandle, error) {
+	mp, err := GetMountPoint(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MountHandle{
+		source:     source,
+		mountPoint: mp,
+	}, nil
+}
+
+// Returns the name of the archive or directory, as it was passed when it was
+// mounted.
+func (m *MountHandle) Source() string {
+	return m.source
+}
+
+// Returns the point in the search path that the archive or directory was
+// mounted at.
+func (m *MountHandle) MountPoint() string {
+	return m.mountPoint
+}
+
+// Returns the files opened for reading with this package that come from the
+// archive or directory and are still open. PhysicsFS won't unmount it while
+// there are any.
+func (m *MountHandle) OpenFiles() []*File {
+	files, _ := filesFrom(m.source)
+	return files
+}
+
+type unmountOptions struct {
+	wait  context.Context
+	force bool
+}
+
+// An option that changes how MountHandle.Unmount deals with open files.
+type UnmountOption func(*unmountOptions)
+
+// Returns an UnmountOption that makes Unmount wait until all of the files that
+// come from the mount have been closed, or until ctx is done, in which case
+// Unmount returns ctx.Err().
+func WaitForFiles(ctx context.Context) UnmountOption {
+	return func(o *unmountOptions) {
+		o.wait = ctx
+	}
+}
+
+// Returns an UnmountOption that makes Unmount close any files that come from
+// the mount first. Anything still using them will get errors from then on. If
+// used with WaitForFiles, the files are only closed if ctx is done first.
+func ForceClose() UnmountOption {
+	return func(o *unmountOptions) {
+		o.force = true
+	}
+}
+
+// Removes the archive or directory from the search path. By default, this
+// fails with ErrFilesStillOpen if any files from it are still open, but opts
+// can be used to wait for them to be closed or to close them. Only files
+// opened with this package can be waited for or closed. Returns an error, if
+// any.
+func (m *MountHandle) Unmount(opts ...UnmountOption) error {
+	var o unmountOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.wait != nil {
+		err := m.wait(o.wait)
+		if (err != nil) && !o.force {
+			return err
+		}
+	}
+
+	if o.force {
+		files, _ := filesFrom(m.source)
+		for _, f := range files {
+			err := f.Close()
+			if (err != nil) && !errors.Is(err, ErrClosed) {
+				return err
+			}
+		}
+	}
+
+	return Unmount(m.source)
+}
+
+// Waits until there are no open files from the mount, or until ctx is done.
+func (m *MountHandle) wait(ctx context.Context) error {
+	for {
+		files, closed := filesFrom(m.source)
+		if len(files) == 0 {
+			return nil
+		}
+
+		select {
+		case <-closed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Describes an archive or directory in the search path.
+type MountInfo struct {
+	// The name that the archive or directory was mounted under, as returned
+	// by GetSearchPath().
+	Source string
+
+	// Where it is mounted in the search path, as returned by GetMountPoint().
+	MountPoint string
+
+	// Whether it is a native directory rather than an archive.
+	Native bool
+
+	// The type of archive, if it isn't a native directory. PhysicsFS doesn't
+	// report which archiver it used, so the type is worked out from the
+	// source's extension, or from the start of the archive's contents. If the
+	// type can't be worked out, this is the zero ArchiveInfo.
+	Archive ArchiveInfo
+
+	// The number of files opened for reading with this package that come
+	// from the archive or directory and are still open.
+	OpenFiles int
+}
+
+// The signatures of archive types that are commonly given other extensions.
+var archiveMagic = []struct {
+	ext   string
+	magic string
+}{
+	{"ZIP", "PK\x03\x04"},
+	{"7Z", "7z\xbc\xaf\x27\x1c"},
+}
+
+// The number of bytes at the start of an archive needed to recognize it.
+const headSize = 8
+
+// Returns the first headSize bytes of r, or as many of them as can be read.
+func readHead(r io.ReaderAt) []byte {
+	buf := make([]byte, headSize)
+	n, _ := r.ReadAt(buf, 0)
+
+	return buf[:n]
+}
+
+// Works out what the native file or directory name is, for something that's
+// about to be mounted or that was mounted without this package.
+func nativeSourceKind(name string) sourceKind {
+	if fi, err := os.Stat(name); (err == nil) && fi.IsDir() {
+		return sourceKind{native: true}
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return sourceKind{}
+	}
+	defer file.Close()
+
+	return sourceKind{head: readHead(file)}
+}
+
+// Works out the type of the archive source, which starts with head, from the
+// supported types.
+func archiveType(source string, head []byte, types []ArchiveInfo) ArchiveInfo {
+	find := func(ext string) (ArchiveInfo, bool) {
+		for _, ai := range types {
+			if strings.EqualFold(ai.Extension, ext) {
+				return ai, true
+			}
+		}
+		return ArchiveInfo{}, false
+	}
+
+	if ext := filepath.Ext(source); ext != "" {
+		if ai, ok := find(ext[1:]); ok {
+			return ai
+		}
+	}
+
+	for _, m := range archiveMagic {
+		if strings.HasPrefix(string(head), m.magic) {
+			ai, _ := find(m.ext)
+			return ai
+		}
+	}
+
+	return ArchiveInfo{}
+}
+
+// Returns information about everything in the search path, in the order that
+// it is searched in. Returns an error, if any.
+func Mounts() ([]MountInfo, error) {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+
+	sp, err := GetSearchPath()
+	if err != nil {
+		return nil, err
+	}
+
+	open := make(map[string]int)
+	openFiles.Lock()
+	for f := range openFiles.files {
+		open[f.source]++
+	}
+	openFiles.Unlock()
+
+	types := SupportedArchiveTypes()
+
+	mounts := make([]MountInfo, 0, len(sp))
+	for _, source := range sp {
+		mp, err := GetMountPoint(source)
+		if err != nil {
+			// Removed in the meantime.
+			continue
+		}
+
+		info := MountInfo{
+			Source:     source,
+			MountPoint: mp,
+			OpenFiles:  open[source],
+		}
+		// Only things mounted without this package are looked at again.
+		kind, ok := mountKind(source)
+		if !ok {
+			kind = nativeSourceKind(source)
+		}
+		info.Native = kind.native
+		if !kind.native {
+			info.Archive = archiveType(source, kind.head, types)
+		}
+
+		mounts = append(mounts, info)
+	}
+
+	return mounts, nil
+}
@@ -0,0 +1,142 @@
+package physfs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMountHandle(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	m, err := OpenMount("../test", "mod", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (m.Source() != "../test") || (m.MountPoint() != "mod/") {
+		t.Fatalf("Unexpected mount: %q at %q\n", m.Source(), m.MountPoint())
+	}
+
+	file, err := Open("mod/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if files := m.OpenFiles(); (len(files) != 1) || (files[0] != file) {
+		t.Fatalf("Unexpected open files: %v\n", files)
+	}
+
+	err = m.Unmount()
+	if !errors.Is(err, ErrFilesStillOpen) {
+		t.Fatalf("Expected ErrFilesStillOpen, got %v\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = m.Unmount(WaitForFiles(ctx))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v\n", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		file.Close()
+	}()
+	err = m.Unmount(WaitForFiles(context.Background()))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if Exists("mod/zip1.aoi") {
+		t.Fatalf("Still mounted.\n")
+	}
+
+	m, err = OpenMount("../test", "mod", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	file, err = Open("mod/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = m.Unmount(ForceClose())
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	_, err = file.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Expected ErrClosed, got %v\n", err)
+	}
+
+	_, err = GetMount("../test")
+	if !errors.Is(err, ErrNotMounted) {
+		t.Fatalf("Expected ErrNotMounted, got %v\n", err)
+	}
+}
+
+func TestMounts(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test", "dir", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	data, err := os.ReadFile("../test/a.zip")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MountMemory(data, "a.zip", "mem", false)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	// Named after a native directory, which has nothing to do with it.
+	err = MountMemory(data, "../httptest", "shadow", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	file, err := Open("dir1/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+
+	mounts, err := Mounts()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if len(mounts) != 4 {
+		t.Fatalf("Unexpected mounts: %+v\n", mounts)
+	}
+
+	mem, dir, zip, shadow := mounts[0], mounts[1], mounts[2], mounts[3]
+	if (mem.Source != "a.zip") || (mem.MountPoint != "mem/") || mem.Native || (mem.Archive.Extension != "ZIP") {
+		t.Errorf("Unexpected memory mount: %+v\n", mem)
+	}
+	if (dir.Source != "../test") || !dir.Native || (dir.OpenFiles != 0) {
+		t.Errorf("Unexpected directory mount: %+v\n", dir)
+	}
+	if (zip.Source != "../test/zip1.aoi") || zip.Native || (zip.Archive.Extension != "ZIP") || (zip.OpenFiles != 1) {
+		t.Errorf("Unexpected archive mount: %+v\n", zip)
+	}
+	if (shadow.Source != "../httptest") || shadow.Native || (shadow.Archive.Extension != "ZIP") {
+		t.Errorf("Unexpected memory mount: %+v\n", shadow)
+	}
+}
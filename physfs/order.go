@@ -0,0 +1,356 @@
+package physfs
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// How to put something back into the search path after it has been removed,
+// so that the search path can be rearranged.
+type mountRecord struct {
+	// Mounts the source again at mp, as part of b, or nil if that isn't
+	// possible, as with MountFile.
+	remount func(b *batch, mp string, app bool) error
+
+	// The root set with SetRoot, if any.
+	root string
+
+	// What was mounted, as found when it was mounted.
+	kind sourceKind
+}
+
+// Describes what was mounted, so that Mounts doesn't have to look at the source
+// again, which may not even exist outside of the search path.
+type sourceKind struct {
+	// Whether the source is a native directory.
+	native bool
+
+	// The start of the archive's contents, which identifies its type.
+	head []byte
+}
+
+var mountRecords struct {
+	sync.Mutex
+
+	records map[string]*mountRecord
+}
+
+// Held while the search path is being rearranged or captured, and while
+// Mount and OpenMount run, as their options may briefly mount an archive
+// somewhere else to look inside it.
+var orderLock sync.Mutex
+
+// Records what source, which has just been mounted, is and how it can be
+// mounted again. PhysicsFS reports success when mounting something that's
+// already mounted, so an existing record is left alone.
+func recordMount(source string, kind sourceKind, remount func(b *batch, mp string, app bool) error) {
+	mountRecords.Lock()
+	defer mountRecords.Unlock()
+
+	if mountRecords.records == nil {
+		mountRecords.records = make(map[string]*mountRecord)
+	}
+	if _, ok := mountRecords.records[source]; !ok {
+		mountRecords.records[source] = &mountRecord{remount: remount, kind: kind}
+	}
+}
+
+// Returns what source was when it was mounted, if it was mounted by this
+// package.
+func mountKind(source string) (sourceKind, bool) {
+	mountRecords.Lock()
+	defer mountRecords.Unlock()
+
+	r, ok := mountRecords.records[source]
+	if !ok {
+		return sourceKind{}, false
+	}
+
+	return r.kind, true
+}
+
+// Records the root that has been set for source.
+func recordRoot(source, root string) {
+	mountRecords.Lock()
+	defer mountRecords.Unlock()
+
+	if r, ok := mountRecords.records[source]; ok {
+		r.root = strings.Trim(root, "/")
+	}
+}
+
+// Forgets about source after it has been unmounted.
+func forgetMount(source string) {
+	mountRecords.Lock()
+	defer mountRecords.Unlock()
+
+	delete(mountRecords.records, source)
+}
+
+// Forgets about everything after PhysicsFS has been deinitialized.
+func forgetMounts() {
+	mountRecords.Lock()
+	defer mountRecords.Unlock()
+
+	mountRecords.records = nil
+}
+
+// An entry in the search path, as it was before it was rearranged.
+type searchPathEntry struct {
+	mountRecord
+
+	source     string
+	mountPoint string
+}
+
+// Returns what's needed to mount source again. Anything that wasn't mounted
+// through this package is assumed to be an archive or directory on disk.
+func getSearchPathEntry(source string) (searchPathEntry, error) {
+	mp, err := GetMountPoint(source)
+	if err != nil {
+		return searchPathEntry{}, err
+	}
+
+	e := searchPathEntry{
+		source:     source,
+		mountPoint: mp,
+	}
+
+	mountRecords.Lock()
+	r, ok := mountRecords.records[source]
+	if ok {
+		e.mountRecord = *r
+	}
+	mountRecords.Unlock()
+
+	if !ok {
+		e.remount = func(b *batch, mp string, app bool) error {
+			return mountSource(b, source, mp, app)
+		}
+	}
+
+	return e, nil
+}
+
+// Mounts e again, where it was before, as part of b.
+func (e searchPathEntry) mount(b *batch, app bool) error {
+	if err := e.remount(b, e.mountPoint, app); err != nil {
+		return err
+	}
+
+	if e.root != "" {
+		if err := setRoot(b, e.source, e.root); err != nil {
+			unmount(b, e.source)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rearranges the search path from cur, its current order, to target, which
+// must contain the same entries. PhysicsFS can only add to either end of the
+// search path, so whichever end needs fewer entries moved is removed and added
+// again in the new order. If anything goes wrong, the previous order is
+// restored. The changes are made as part of b. The caller must hold orderLock.
+func reorder(b *batch, op string, cur, target []string) error {
+	n := len(cur)
+	if len(target) != n {
+		// Something was mounted or removed in the meantime.
+		return &PathError{Op: op, Code: ErrOtherError}
+	}
+
+	prefix := 0
+	for (prefix < n) && (cur[prefix] == target[prefix]) {
+		prefix++
+	}
+	if prefix == n {
+		return nil
+	}
+
+	suffix := 0
+	for (suffix < n) && (cur[n-1-suffix] == target[n-1-suffix]) {
+		suffix++
+	}
+
+	// Both lists are in the order that the entries need to be added in.
+	var removed, added []string
+	app := prefix >= suffix
+	if app {
+		removed = slices.Clone(cur[prefix:])
+		added = slices.Clone(target[prefix:])
+	} else {
+		removed = slices.Clone(cur[:n-suffix])
+		added = slices.Clone(target[:n-suffix])
+		slices.Reverse(removed)
+		slices.Reverse(added)
+	}
+
+	entries := make(map[string]searchPathEntry, len(removed))
+	for _, source := range removed {
+		e, err := getSearchPathEntry(source)
+		if err != nil {
+			return err
+		}
+		if e.remount == nil {
+			return &PathError{Op: op, Path: source, Code: ErrUnsupported}
+		}
+		if files, _ := filesFrom(source); len(files) > 0 {
+			return &PathError{Op: op, Path: source, Code: ErrFilesStillOpen}
+		}
+		entries[source] = e
+	}
+
+	// Removed from the end that's furthest from the rest of the search path,
+	// so that if one fails, adding back the ones that are gone restores the
+	// order.
+	for i := len(removed) - 1; i >= 0; i-- {
+		if err := unmount(b, removed[i]); err != nil {
+			return errors.Join(err, remountAll(b, entries, removed[i+1:], app))
+		}
+	}
+
+	for i, source := range added {
+		if err := entries[source].mount(b, app); err != nil {
+			for _, source := range added[:i] {
+				unmount(b, source)
+			}
+			return errors.Join(err, remountAll(b, entries, removed, app))
+		}
+	}
+
+	return nil
+}
+
+// Mounts sources again, in order, as part of b, returning the errors of any
+// that fail.
+func remountAll(b *batch, entries map[string]searchPathEntry, sources []string, app bool) error {
+	var errs []error
+	for _, source := range sources {
+		if err := entries[source].mount(b, app); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Mounts dir at mp in the same way as Mount, but at position index in the
+// search path rather than at either end, with 0 being searched first. An
+// index equal to the length of the search path is the same as appending.
+// Mounting something that is already in the search path does nothing, as
+// with Mount.
+//
+// PhysicsFS can only add to either end of the search path, so whatever is in
+// the way is removed and mounted again afterwards. This fails with
+// ErrFilesStillOpen if any files from those are open, and with ErrUnsupported
+// if any of them were mounted with MountFile, and leaves the search path as it
+// was if anything goes wrong. Other goroutines may briefly find files missing
+// from the search path while it is being rearranged. Returns an error, if any.
+func MountAt(index int, dir, mp string, opts ...MountOption) error {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+	b := new(batch)
+	defer b.end()
+
+	sp, err := GetSearchPath()
+	if err != nil {
+		return err
+	}
+	if (index < 0) || (index > len(sp)) {
+		return &PathError{Op: "mountat", Path: dir, Code: ErrInvalidArgument}
+	}
+
+	app := index*2 >= len(sp)
+	source, err := mount(b, dir, mp, app, opts)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(sp, source) {
+		return nil
+	}
+
+	cur, err := GetSearchPath()
+	if err != nil {
+		unmount(b, source)
+		return err
+	}
+
+	// Worked out from cur, in case something else was mounted in the meantime.
+	target := slices.DeleteFunc(slices.Clone(cur), func(s string) bool {
+		return s == source
+	})
+	target = slices.Insert(target, min(index, len(target)), source)
+	if err := reorder(b, "mountat", cur, target); err != nil {
+		unmount(b, source)
+		return err
+	}
+
+	return nil
+}
+
+// Moves source, which must already be in the search path, to position index
+// in it, with 0 being searched first. This fails in the same way as MountAt,
+// leaving the search path as it was. Returns an error, if any.
+func MoveMount(source string, index int) error {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+	b := new(batch)
+	defer b.end()
+
+	sp, err := GetSearchPath()
+	if err != nil {
+		return err
+	}
+
+	i := slices.Index(sp, source)
+	if i < 0 {
+		return &PathError{Op: "movemount", Path: source, Code: ErrNotMounted}
+	}
+	if (index < 0) || (index >= len(sp)) {
+		return &PathError{Op: "movemount", Path: source, Code: ErrInvalidArgument}
+	}
+
+	target := slices.Delete(slices.Clone(sp), i, i+1)
+	target = slices.Insert(target, index, source)
+
+	return reorder(b, "movemount", sp, target)
+}
+
+// Rearranges the search path so that sources, each of which must already be
+// in it, are searched first, in the given order. Anything else in the search
+// path keeps its order after them. This fails in the same way as MountAt,
+// leaving the search path as it was. Returns an error, if any.
+func SetMountOrder(sources []string) error {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+	b := new(batch)
+	defer b.end()
+
+	sp, err := GetSearchPath()
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		if listed[source] {
+			return &PathError{Op: "setmountorder", Path: source, Code: ErrInvalidArgument}
+		}
+		if !slices.Contains(sp, source) {
+			return &PathError{Op: "setmountorder", Path: source, Code: ErrNotMounted}
+		}
+		listed[source] = true
+	}
+
+	target := slices.Clone(sources)
+	for _, source := range sp {
+		if !listed[source] {
+			target = append(target, source)
+		}
+	}
+
+	return reorder(b, "setmountorder", sp, target)
+}
@@ -0,0 +1,125 @@
+package physfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMountOrder(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dirs := make(map[string]string)
+	for _, name := range []string{"base", "patch", "mods"} {
+		dir := filepath.Join(t.TempDir(), name)
+		err := os.Mkdir(dir, 0755)
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		err = os.WriteFile(filepath.Join(dir, "which"), []byte(name), 0644)
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		dirs[name] = dir
+	}
+
+	check := func(order ...string) {
+		t.Helper()
+
+		sp, err := GetSearchPath()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		var names []string
+		for _, dir := range sp {
+			names = append(names, filepath.Base(dir))
+		}
+		if !slices.Equal(names, order) {
+			t.Fatalf("Expected search path %v, got %v\n", order, names)
+		}
+
+		data, err := ReadFile("which")
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		if string(data) != order[0] {
+			t.Fatalf("Expected %q to be found first, got %q\n", order[0], data)
+		}
+	}
+
+	err := Mount(dirs["mods"], "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(dirs["base"], "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	err = MountAt(1, dirs["patch"], "")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	check("mods", "patch", "base")
+
+	err = MoveMount(dirs["base"], 0)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	check("base", "mods", "patch")
+
+	err = SetMountOrder([]string{dirs["patch"]})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	check("patch", "base", "mods")
+
+	file, err := Open("which")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MoveMount(dirs["patch"], 2)
+	if !errors.Is(err, ErrFilesStillOpen) {
+		t.Fatalf("Expected ErrFilesStillOpen, got %v\n", err)
+	}
+	file.Close()
+	check("patch", "base", "mods")
+
+	err = MoveMount("missing", 0)
+	if !errors.Is(err, ErrNotMounted) {
+		t.Fatalf("Expected ErrNotMounted, got %v\n", err)
+	}
+	err = MoveMount(dirs["base"], 3)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Expected ErrInvalidArgument, got %v\n", err)
+	}
+	err = SetMountOrder([]string{dirs["base"], dirs["base"]})
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("Expected ErrInvalidArgument, got %v\n", err)
+	}
+	check("patch", "base", "mods")
+
+	data, err := os.ReadFile("../test/zip1.aoi")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MountMemory(data, "zip1.zip", "mem", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = MoveMount("zip1.zip", 0)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !Exists("mem/dir1/file1") {
+		t.Fatalf("Memory mount lost after moving.\n")
+	}
+}
@@ -1,28 +1,38 @@
 // The physfs package provides Go bindings for the PhysicsFS
 // archive-abstraction library.
+//
+// The bindings use the PhysicsFS 3.x API, but can also be built against the
+// headers of older versions. The version is detected at build time, and
+// anything that the version being built against can't do, such as SetRoot
+// before 3.1 or MountMemory before 2.1, fails with an error with the code
+// ErrUnsupported.
 package physfs
 
 import (
-	"errors"
 	"os"
 	"path"
+	"runtime"
+	"runtime/cgo"
+	"strings"
+	"sync"
 	"time"
 	"unsafe"
 )
 
 // #cgo LDFLAGS: -lphysfs
 // #include <stdlib.h>
-// #include <physfs.h>
+// #include "compat.h"
 //
 // #include "wrapcb.h"
 import "C"
 
 // A type used to store information about supported archive types.
 type ArchiveInfo struct {
-	Extension   string
-	Description string
-	Author      string
-	URL         string
+	Extension        string
+	Description      string
+	Author           string
+	URL              string
+	SupportsSymlinks bool
 }
 
 // Used to store information about the version of PhysicsFS go-physfs was linked
@@ -34,31 +44,55 @@ type Version struct {
 }
 
 // The func type required by GetCdRomDirsCallback and GetSearchPathCallback.
-type StringCallback func(interface{}, string)
+type StringCallback[T any] func(T, string)
 
 type contextStringCallback struct {
-	cb   StringCallback
-	data interface{}
+	cb  func(string)
+	err error
 }
 
 //export wrapStringCallback
-func wrapStringCallback(data unsafe.Pointer, str *C.char) {
-	csc := (*contextStringCallback)(data)
-	csc.cb(csc.data, C.GoString(str))
+func wrapStringCallback(data C.uintptr_t, str *C.char) {
+	csc := cgo.Handle(data).Value().(*contextStringCallback)
+	if csc.err != nil {
+		// A previous call panicked, so skip the rest.
+		return
+	}
+	defer recoverCallback(&csc.err)
+
+	csc.cb(C.GoString(str))
 }
 
 // The func type required by EnumerateFilesCallback.
-type EnumFilesCallback func(interface{}, string, string)
+type EnumFilesCallback[T any] func(T, string, string)
 
 type contextEnumFilesCallback struct {
-	cb   EnumFilesCallback
-	data interface{}
+	cb  func(string, string)
+	err error
 }
 
 //export wrapEnumFilesCallback
-func wrapEnumFilesCallback(data unsafe.Pointer, origdir *C.char, fname *C.char) {
-	cefc := (*contextEnumFilesCallback)(data)
-	cefc.cb(cefc.data, C.GoString(origdir), C.GoString(fname))
+func wrapEnumFilesCallback(data C.uintptr_t, origdir *C.char, fname *C.char) (r C.PHYSFS_EnumerateCallbackResult) {
+	cefc := cgo.Handle(data).Value().(*contextEnumFilesCallback)
+	defer func() {
+		// PhysicsFS is told to stop, rather than to fail, so that the
+		// panic is reported instead of an error from PhysicsFS.
+		if v := recover(); v != nil {
+			cefc.err = &PanicError{Value: v}
+			r = C.PHYSFS_ENUM_STOP
+		}
+	}()
+
+	cefc.cb(C.GoString(origdir), C.GoString(fname))
+	return C.PHYSFS_ENUM_OK
+}
+
+// Converts a panic in a callback into a *PanicError stored in err, as panics
+// can't be allowed to unwind through PhysicsFS. Must be deferred directly.
+func recoverCallback(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r}
+	}
 }
 
 // Returns a boolean indicating if PhysicsFS has been initialized.
@@ -70,37 +104,60 @@ func IsInit() bool {
 	return false
 }
 
-// Initialize PhysicsFS. Must be called before most functions will work. Returns
-// an error, if any.
+// Initialize PhysicsFS. Must be called before most functions will work. If a
+// minimum version has been set with RequireVersion and the linked library is
+// older than that, PhysicsFS is not initialized and a *VersionError is
+// returned. Returns an error, if any.
 func Init() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := checkVersion(); err != nil {
+		return err
+	}
+
 	arg0 := C.CString(os.Args[0])
 	defer C.free(unsafe.Pointer(arg0))
 	if int(C.PHYSFS_init(arg0)) != 0 {
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("init", "")
 }
 
 // Deinitialize PhysicsFS. This closes any files that have been opened by
 // PhysicsFS, clears the search and write paths, forgets other settings, such as
 // whether or not symbolic links are permitted, and cleans up other related
-// resources.
+// resources, including any archivers registered with RegisterArchiver. Files
+// that are still open act as though they had been closed.
 func Deinit() error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	if int(C.PHYSFS_deinit()) != 0 {
+		resetArchivers()
+		forgetOpenFiles()
+		forgetMounts()
+		notify(nil, Event{Kind: EventDeinit})
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("deinit", "")
 }
 
 // Returns a string containing an error message related to the last error
 // that occured in a PhysicsFS function. Isn't necessary to call in most cases,
 // as functions that generate said error return them as an error in
-// go-physfs.
+// go-physfs. PhysicsFS keeps track of errors per thread, so this is only
+// meaningful if the calling goroutine has been locked to its thread with
+// runtime.LockOSThread() since the failing call.
 func GetLastError() string {
-	cerr := C.PHYSFS_getLastError()
-	return C.GoString(cerr)
+	code := C.PHYSFS_getLastErrorCode()
+	if code == C.PHYSFS_ERR_OK {
+		return ""
+	}
+
+	return C.GoString(C.PHYSFS_getErrorByCode(code))
 }
 
 // Returns a Version containing the version of PhysicsFS that the bindings were
@@ -126,9 +183,13 @@ func GetLinkedVersion() (ver *Version) {
 }
 
 // Returns an []ArchiveInfo containing information about all the archives
-// supported by PhysicsFS.
+// supported by PhysicsFS. Newer versions of PhysicsFS only report them once it
+// has been initialized.
 func SupportedArchiveTypes() (ai []ArchiveInfo) {
 	cai := C.PHYSFS_supportedArchiveTypes()
+	if cai == nil {
+		return nil
+	}
 
 	i := uintptr(0)
 	for {
@@ -142,6 +203,7 @@ func SupportedArchiveTypes() (ai []ArchiveInfo) {
 		a.Description = C.GoString(archive.description)
 		a.Author = C.GoString(archive.author)
 		a.URL = C.GoString(archive.url)
+		a.SupportsSymlinks = int(C.archiveSupportsSymlinks(archive)) != 0
 
 		ai = append(ai, a)
 
@@ -164,6 +226,31 @@ func GetUserDir() string {
 	return C.GoString(cdir)
 }
 
+// Returns the directory that the application should write its own files to,
+// such as its configuration and saved games, creating it if it doesn't exist.
+// org and app are the names of the application's developer and of the
+// application itself, and are used to build a path that is appropriate for
+// the platform, such as "~/.local/share/app" on Linux and
+// "C:\Users\user\AppData\Roaming\org\app" on Windows. The directory
+// is returned with a trailing separator, and isn't added to the search path or
+// made the write dir; that's up to the caller. Returns an error, if any.
+func GetPrefDir(org, app string) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	corg := C.CString(org)
+	defer C.free(unsafe.Pointer(corg))
+	capp := C.CString(app)
+	defer C.free(unsafe.Pointer(capp))
+
+	dir := C.PHYSFS_getPrefDir(corg, capp)
+	if dir == nil {
+		return "", lastError("getprefdir", app)
+	}
+
+	return C.GoString(dir), nil
+}
+
 // Returns the current write directory. Files written using PhysicsFS can only
 // be inside the write directory. Default is nowhere, which will return a blank
 // string.
@@ -172,15 +259,29 @@ func GetWriteDir() string {
 	return C.GoString(cdir)
 }
 
-// Set the current write directory. Returns an error, if any.
+// Set the current write directory. Use "" to have no write directory. Returns
+// an error, if any.
 func SetWriteDir(dir string) error {
-	cdir := C.CString(dir)
-	defer C.free(unsafe.Pointer(cdir))
+	return setWriteDir(nil, dir)
+}
+
+// Sets the write dir as part of b.
+func setWriteDir(b *batch, dir string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// PhysicsFS uses NULL for no write directory.
+	var cdir *C.char
+	if dir != "" {
+		cdir = C.CString(dir)
+		defer C.free(unsafe.Pointer(cdir))
+	}
 	if int(C.PHYSFS_setWriteDir(cdir)) != 0 {
+		notify(b, Event{Kind: EventWriteDir, Path: dir})
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("setwritedir", dir)
 }
 
 // Gets the directory seperator for the operating system. In Windows returns
@@ -199,6 +300,9 @@ func GetDirSeparator() string {
 // extension. If pre is true the archives are prepended to the search path; if
 // false they are appended.
 func SetSaneConfig(org, app, ext string, cd, pre bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cdArg := 0
 	if cd {
 		cdArg = 1
@@ -218,10 +322,11 @@ func SetSaneConfig(org, app, ext string, cd, pre bool) error {
 
 	r := C.PHYSFS_setSaneConfig(corg, capp, cext, C.int(cdArg), C.int(preArg))
 	if int(r) != 0 {
+		notify(nil, Event{Kind: EventConfig})
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("setsaneconfig", "")
 }
 
 // Returns a []string containing all detected CD-ROM directories and an error,
@@ -230,10 +335,13 @@ func SetSaneConfig(org, app, ext string, cd, pre bool) error {
 // this function and related ones refer to CD-ROMs, they will detect any type of
 // supported disc, including DVDs and Blu-Ray discs.
 func GetCdRomDirs() (sp []string, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	csp := C.PHYSFS_getCdRomDirs()
 
 	if csp == nil {
-		return nil, errors.New(GetLastError())
+		return nil, lastError("getcdromdirs", "")
 	}
 
 	i := uintptr(0)
@@ -252,46 +360,58 @@ func GetCdRomDirs() (sp []string, err error) {
 	return sp, nil
 }
 
-// Call c for each detected CD-ROM directrory, passing it d and the dir.
-func GetCdRomDirsCallback(c StringCallback, d interface{}) {
+// Call c for each detected CD-ROM directrory, passing it d and the dir. If c
+// panics, it isn't called again and the panic is returned as a *PanicError.
+func GetCdRomDirsCallback[T any](c StringCallback[T], d T) error {
 	csc := &contextStringCallback{
-		cb:   c,
-		data: d,
+		cb: func(dir string) { c(d, dir) },
 	}
+	h := cgo.NewHandle(csc)
+	defer h.Delete()
 
-	C.getCdRomDirsCallback(unsafe.Pointer(csc))
+	C.getCdRomDirsCallback(C.uintptr_t(h))
+	return csc.err
 }
 
-// Call c for each entry in the SearchPath, passing it d and the dir.
-func GetSearchPathCallback(c StringCallback, d interface{}) {
+// Call c for each entry in the SearchPath, passing it d and the dir. If c
+// panics, it isn't called again and the panic is returned as a *PanicError.
+func GetSearchPathCallback[T any](c StringCallback[T], d T) error {
 	csc := &contextStringCallback{
-		cb:   c,
-		data: d,
+		cb: func(dir string) { c(d, dir) },
 	}
+	h := cgo.NewHandle(csc)
+	defer h.Delete()
 
-	C.getSearchPathCallback(unsafe.Pointer(csc))
+	C.getSearchPathCallback(C.uintptr_t(h))
+	return csc.err
 }
 
-// Call c for each file in dir, passing it d, dir, and the file.
-func EnumerateFilesCallback(dir string, c EnumFilesCallback, d interface{}) {
+// Call c for each file in dir, passing it d, dir, and the file. If c panics,
+// PhysicsFS stops enumerating and the panic is returned as a *PanicError.
+func EnumerateFilesCallback[T any](dir string, c EnumFilesCallback[T], d T) error {
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
 
 	cefc := &contextEnumFilesCallback{
-		cb:   c,
-		data: d,
+		cb: func(origdir, fname string) { c(d, origdir, fname) },
 	}
+	h := cgo.NewHandle(cefc)
+	defer h.Delete()
 
-	C.enumerateFilesCallback(cdir, unsafe.Pointer(cefc))
+	C.enumerateFilesCallback(cdir, C.uintptr_t(h))
+	return cefc.err
 }
 
 // Returns a []string with the current search path, in order, and an error, if
 // any.
 func GetSearchPath() (sp []string, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	csp := C.PHYSFS_getSearchPath()
 
 	if csp == nil {
-		return nil, errors.New(GetLastError())
+		return nil, lastError("getsearchpath", "")
 	}
 
 	i := uintptr(0)
@@ -310,18 +430,30 @@ func GetSearchPath() (sp []string, err error) {
 	return sp, nil
 }
 
-//func GetSearchPathCallback(c StringCallback, d interface{}) {
-//	C.PHYSFS_getSearchPathCallback((*[0]uint8)(unsafe.Pointer(&c)), unsafe.Pointer(&d))
-//}
+// Held while changing whether symbolic links are permitted, so that whether it
+// changed is known.
+var symlinksLock sync.Mutex
 
 // Enable or disable the following of symbolic links. Default is disabled.
 func PermitSymbolicLinks(set bool) {
+	permitSymbolicLinks(nil, set)
+}
+
+// Enables or disables the following of symbolic links as part of b.
+func permitSymbolicLinks(b *batch, set bool) {
 	s := C.int(0)
 	if set {
 		s = 1
 	}
 
+	symlinksLock.Lock()
+	defer symlinksLock.Unlock()
+
+	if set == SymbolicLinksPermitted() {
+		return
+	}
 	C.PHYSFS_permitSymbolicLinks(s)
+	notify(b, Event{Kind: EventSymbolicLinks})
 }
 
 // Return whether or not following of symbolic links is currently enabled.
@@ -336,13 +468,11 @@ func SymbolicLinksPermitted() bool {
 // Returns true if the named path exists and is a symbolic link, otherwise
 // returns false.
 func IsSymbolicLink(n string) bool {
-	cn := C.CString(n)
-	defer C.free(unsafe.Pointer(cn))
-	if int(C.PHYSFS_isSymbolicLink(cn)) != 0 {
-		return true
-	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	return false
+	info, err := statInfo("issymboliclink", n)
+	return (err == nil) && (info.Type == FileTypeSymlink)
 }
 
 // Returns the real path to the specified file/directory. For example, if you
@@ -352,6 +482,9 @@ func IsSymbolicLink(n string) bool {
 // 'C:\mygame' is in your search path, 'C:\mygame' is returned. Also returns an
 // error, if any.
 func GetRealDir(n string) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cn := C.CString(n)
 	defer C.free(unsafe.Pointer(cn))
 	dir := C.PHYSFS_getRealDir(cn)
@@ -360,18 +493,21 @@ func GetRealDir(n string) (string, error) {
 		return C.GoString(dir), nil
 	}
 
-	return C.GoString(dir), errors.New(GetLastError())
+	return C.GoString(dir), lastError("getrealdir", n)
 }
 
 // Returns a []string containing the files and directories in the specified
 // directory in your search path, and an error, if any.
 func EnumerateFiles(dir string) (list []string, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
 	clist := C.PHYSFS_enumerateFiles(cdir)
 
 	if clist == nil {
-		return nil, errors.New(GetLastError())
+		return nil, lastError("enumeratefiles", dir)
 	}
 
 	i := uintptr(0)
@@ -405,13 +541,16 @@ func Exists(n string) bool {
 // Deletes the specified file or directory. Only deletes empty directories.
 // Returns an error, if any.
 func Delete(n string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cn := C.CString(n)
 	defer C.free(unsafe.Pointer(cn))
 	if int(C.PHYSFS_delete(cn)) != 0 {
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("delete", n)
 }
 
 // A convienece function that will recurse through a directory, deleting all
@@ -449,25 +588,26 @@ func DeleteRecurse(dir string) (err error) {
 
 // Returns true if dir exists and is a directory. Otherwise, returns false.
 func IsDirectory(dir string) bool {
-	cdir := C.CString(dir)
-	defer C.free(unsafe.Pointer(cdir))
-	if int(C.PHYSFS_isDirectory(cdir)) != 0 {
-		return true
-	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	return false
+	info, err := statInfo("isdirectory", dir)
+	return (err == nil) && (info.Type == FileTypeDirectory)
 }
 
 // Creates the specified directory inside the write path. Will create any parent
 // directories that don't exist. Returns an error, if any.
 func Mkdir(dir string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
 	if int(C.PHYSFS_mkdir(cdir)) != 0 {
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("mkdir", dir)
 }
 
 // Adds an archive or directory dir to the search path, mounting it at the
@@ -476,8 +616,29 @@ func Mkdir(dir string) error {
 // is prepended. While multiple archives/directories may be mounted on the same
 // mount-point, you may not mount the same archive/directory in multiple
 // locations. Attempting to do so will simply do nothing without returning an
-// error. Returns an error, if any.
-func Mount(dir, mp string, app bool) error {
+// error. opts can be used to mount only part of dir; see WithRoot. Returns an
+// error, if any.
+func Mount(dir, mp string, app bool, opts ...MountOption) error {
+	orderLock.Lock()
+	defer orderLock.Unlock()
+
+	_, err := mount(nil, dir, mp, app, opts)
+	return err
+}
+
+// Mounts dir without any options, as part of b.
+func mountSource(b *batch, dir, mp string, app bool) error {
+	mountLock.Lock()
+	defer mountLock.Unlock()
+
+	return mountSourceLocked(b, dir, mp, app)
+}
+
+// Mounts dir in the same way as mountSource. The caller must hold mountLock.
+func mountSourceLocked(b *batch, dir, mp string, app bool) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	a := 0
 	if app {
 		a = 1
@@ -488,16 +649,30 @@ func Mount(dir, mp string, app bool) error {
 	cmp := C.CString(mp)
 	defer C.free(unsafe.Pointer(cmp))
 
+	// PhysicsFS reports success without doing anything if dir is already
+	// mounted, which shouldn't be announced.
+	_, err := GetMountPoint(dir)
+	mounted := err == nil
+
 	if int(C.PHYSFS_mount(cdir, cmp, C.int(a))) != 0 {
+		recordMount(dir, nativeSourceKind(dir), func(b *batch, mp string, app bool) error {
+			return mountSource(b, dir, mp, app)
+		})
+		if !mounted {
+			notifyMount(b, dir)
+		}
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("mount", dir)
 }
 
 // Gets the mount-point of the specified archive/directory. Returns the
 // mount-point (Big surprise...) and an error, if any.
 func GetMountPoint(dir string) (string, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
 	mp := C.PHYSFS_getMountPoint(cdir)
@@ -506,50 +681,93 @@ func GetMountPoint(dir string) (string, error) {
 		return C.GoString(mp), nil
 	}
 
-	return C.GoString(mp), errors.New(GetLastError())
+	return C.GoString(mp), lastError("getmountpoint", dir)
 }
 
-// A legacy function that is now equivalent to
-//		physfs.Mount(dir, "", app)
-func AddToSearchPath(dir string, app bool) error {
-	a := 0
-	if app {
-		a = 1
-	}
+// Makes subdir the root of the archive or directory archive, which must
+// already be in the search path, so that only what is inside subdir is
+// visible, at archive's mount point. This is useful for archives that keep
+// everything inside a single top-level directory. Use "" or "/" to restore
+// the original root. Requires PhysicsFS 3.1 or newer, and returns an error
+// with the code ErrUnsupported if the bindings were built against an older
+// version. Returns an error, if any.
+func SetRoot(archive, subdir string) error {
+	return setRoot(nil, archive, subdir)
+}
 
-	cdir := C.CString(dir)
-	defer C.free(unsafe.Pointer(cdir))
+// Sets the root of archive as part of b.
+func setRoot(b *batch, archive, subdir string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	carchive := C.CString(archive)
+	defer C.free(unsafe.Pointer(carchive))
 
-	if int(C.PHYSFS_addToSearchPath(cdir, C.int(a))) != 0 {
+	// PhysicsFS uses NULL for the original root.
+	var csubdir *C.char
+	if strings.Trim(subdir, "/") != "" {
+		csubdir = C.CString(subdir)
+		defer C.free(unsafe.Pointer(csubdir))
+	}
+
+	if int(C.PHYSFS_setRoot(carchive, csubdir)) != 0 {
+		recordRoot(archive, subdir)
+		notify(b, Event{Kind: EventSetRoot, Path: archive})
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("setroot", archive)
+}
+
+// A legacy function that is now equivalent to
+//		physfs.Mount(dir, "", app)
+func AddToSearchPath(dir string, app bool) error {
+	return mountSource(nil, dir, "", app)
 }
 
 // Remove the specified archive/directory from search path. This will fail if
 // there any files inside the archive/directory that are still open. Returns an
 // error, if any.
-func RemoveFromSearchPath(dir string) error {
+func Unmount(dir string) error {
+	return unmount(nil, dir)
+}
+
+// Removes dir from the search path as part of b.
+func unmount(b *batch, dir string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	cdir := C.CString(dir)
 	defer C.free(unsafe.Pointer(cdir))
-	if int(C.PHYSFS_removeFromSearchPath(cdir)) != 0 {
+	if int(C.PHYSFS_unmount(cdir)) != 0 {
+		forgetMount(dir)
+		notify(b, Event{Kind: EventUnmount, Path: dir})
 		return nil
 	}
 
-	return errors.New(GetLastError())
+	return lastError("unmount", dir)
+}
+
+// A legacy function that is now equivalent to
+//		physfs.Unmount(dir)
+func RemoveFromSearchPath(dir string) error {
+	return Unmount(dir)
 }
 
 // Returns the last time the specified file was modified in either or the local
-// time-zone or UTC, and an error, if any.
+// time-zone or UTC, and an error, if any. If the time isn't known, the error
+// is ErrUnsupported.
 func GetLastModTime(n string) (t time.Time, err error) {
-	cn := C.CString(n)
-	defer C.free(unsafe.Pointer(cn))
-	num := int64(C.PHYSFS_getLastModTime(cn))
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
 
-	if num < 0 {
-		return t, errors.New(GetLastError())
+	info, err := statInfo("getlastmodtime", n)
+	if err != nil {
+		return t, err
+	}
+	if info.ModTime.IsZero() {
+		return t, &PathError{Op: "getlastmodtime", Path: n, Code: ErrUnsupported}
 	}
 
-	return time.Unix(num, 0), nil
+	return info.ModTime, nil
 }
@@ -1,7 +1,10 @@
 package physfs
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"testing"
 )
 
@@ -24,3 +27,114 @@ func TestSupportedArchiveTypes(t *testing.T) {
 		t.Fatalf("Error: %v\n", err)
 	}
 }
+
+func TestConcurrency(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 16; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 50; j++ {
+				file, err := Open("dir1/file1")
+				if err != nil {
+					errs <- err
+					return
+				}
+				_, err = io.ReadAll(file)
+				file.Close()
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				// Every failure has to report its own error.
+				_, err = Open("does/not/exist")
+				if !errors.Is(err, ErrNotFound) {
+					errs <- fmt.Errorf("Expected not found, got %v", err)
+					return
+				}
+			}
+		}()
+
+		go func(i int) {
+			defer wg.Done()
+
+			// Every goroutine mounts the same archive, so these collide with
+			// each other and can fail. They just mustn't crash or race.
+			mp := fmt.Sprintf("mount%v", i)
+			for j := 0; j < 50; j++ {
+				if Mount("../test/a.zip", mp, true) == nil {
+					RemoveFromSearchPath("../test/a.zip")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Error: %v\n", err)
+	}
+}
+
+func TestCallbacks(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	var files []string
+	err = EnumerateFilesCallback("dir1", func(files *[]string, origdir, fname string) {
+		*files = append(*files, origdir+"/"+fname)
+	}, &files)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (len(files) != 1) || (files[0] != "dir1/file1") {
+		t.Fatalf("Unexpected files: %v\n", files)
+	}
+
+	err = GetSearchPathCallback(func(msg string, dir string) {
+		panic(msg)
+	}, "oops")
+	var pe *PanicError
+	if !errors.As(err, &pe) || (pe.Value != "oops") {
+		t.Fatalf("Expected panic, got %v\n", err)
+	}
+
+	calls := 0
+	err = EnumerateFilesCallback("", func(calls *int, origdir, fname string) {
+		*calls++
+		panic("oops")
+	}, &calls)
+	if !errors.As(err, &pe) || (pe.Value != "oops") {
+		t.Fatalf("Expected panic, got %v\n", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected enumeration to stop after a panic, got %v calls\n", calls)
+	}
+}
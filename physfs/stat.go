@@ -0,0 +1,167 @@
+package physfs
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+import "C"
+
+// The type of an entry in the search path or in an archive.
+type FileType int
+
+const (
+	FileTypeRegular FileType = iota
+	FileTypeDirectory
+	FileTypeSymlink
+	FileTypeOther
+)
+
+// Metadata about an entry in the search path or in an archive. Times that
+// aren't known are the zero time.Time. ReadOnly is ignored when returned by an
+// Archive, as those are always read-only.
+type StatInfo struct {
+	Size       int64
+	ModTime    time.Time
+	CreateTime time.Time
+	AccessTime time.Time
+	Type       FileType
+	ReadOnly   bool
+}
+
+// Converts t from the format used by PHYSFS_Stat.
+func fromStatTime(t C.PHYSFS_sint64) time.Time {
+	if t < 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(int64(t), 0)
+}
+
+// Converts t to the format used by PHYSFS_Stat.
+func toStatTime(t time.Time) C.PHYSFS_sint64 {
+	if t.IsZero() {
+		return -1
+	}
+
+	return C.PHYSFS_sint64(t.Unix())
+}
+
+func (info *StatInfo) fromC(stat *C.PHYSFS_Stat) {
+	info.Size = int64(stat.filesize)
+	info.ModTime = fromStatTime(stat.modtime)
+	info.CreateTime = fromStatTime(stat.createtime)
+	info.AccessTime = fromStatTime(stat.accesstime)
+	info.Type = FileType(stat.filetype)
+	info.ReadOnly = int(stat.readonly) != 0
+}
+
+func (info *StatInfo) toC(stat *C.PHYSFS_Stat) {
+	stat.filesize = C.PHYSFS_sint64(info.Size)
+	stat.modtime = toStatTime(info.ModTime)
+	stat.createtime = toStatTime(info.CreateTime)
+	stat.accesstime = toStatTime(info.AccessTime)
+	stat.filetype = C.PHYSFS_FileType(info.Type)
+
+	stat.readonly = 0
+	if info.ReadOnly {
+		stat.readonly = 1
+	}
+}
+
+// The type returned by the Sys method of the os.FileInfo returned by Stat() and
+// File.Stat().
+type FileInfoSys struct {
+	StatInfo
+
+	// The archive or directory in the search path that provides the entry, as
+	// returned by GetRealDir(), or the write directory for files opened for
+	// writing.
+	RealDir string
+}
+
+// Returns the StatInfo for the named file or directory in the search path, or
+// an error for op. The caller must have locked the OS thread.
+func statInfo(op, name string) (info StatInfo, err error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	var stat C.PHYSFS_Stat
+	if int(C.PHYSFS_stat(cname, &stat)) == 0 {
+		return info, lastError(op, name)
+	}
+	info.fromC(&stat)
+
+	return info, nil
+}
+
+// Returns an os.FileInfo describing the named file or directory in the search
+// path. Returns an error, if any.
+func Stat(name string) (os.FileInfo, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	info, err := statInfo("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	fi := &fileInfo{
+		name: path.Base("/" + name),
+		sys:  &FileInfoSys{StatInfo: info},
+	}
+
+	// The root directory doesn't come from anywhere in particular.
+	fi.sys.RealDir, _ = GetRealDir(name)
+
+	return fi, nil
+}
+
+// fileInfo is the os.FileInfo returned by Stat and File.Stat.
+type fileInfo struct {
+	name string
+	sys  *FileInfoSys
+}
+
+func (fi *fileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *fileInfo) Size() int64 {
+	return fi.sys.Size
+}
+
+func (fi *fileInfo) Mode() os.FileMode {
+	var mode os.FileMode = 0644
+	if fi.sys.ReadOnly {
+		mode = 0444
+	}
+
+	switch fi.sys.Type {
+	case FileTypeDirectory:
+		return os.ModeDir | mode | 0111
+	case FileTypeSymlink:
+		return os.ModeSymlink | mode
+	case FileTypeOther:
+		return os.ModeIrregular | mode
+	}
+
+	return mode
+}
+
+func (fi *fileInfo) ModTime() time.Time {
+	return fi.sys.ModTime
+}
+
+func (fi *fileInfo) IsDir() bool {
+	return fi.sys.Type == FileTypeDirectory
+}
+
+func (fi *fileInfo) Sys() interface{} {
+	return fi.sys
+}
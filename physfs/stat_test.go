@@ -0,0 +1,84 @@
+package physfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	fi, err := Stat("dir1/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (fi.Name() != "file1") || (fi.Size() != 16) || fi.IsDir() || fi.ModTime().IsZero() {
+		t.Fatalf("Unexpected info: %v %v %v %v\n", fi.Name(), fi.Size(), fi.IsDir(), fi.ModTime())
+	}
+	sys := fi.Sys().(*FileInfoSys)
+	if !sys.ReadOnly || !strings.HasSuffix(sys.RealDir, "zip1.aoi") {
+		t.Fatalf("Unexpected sys: %+v\n", sys)
+	}
+
+	fi, err = Stat("dir1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !fi.IsDir() || !fi.Mode().IsDir() {
+		t.Fatalf("dir1 is not a directory.\n")
+	}
+
+	file, err := Open("dir1/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer file.Close()
+	fi, err = file.Stat()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if (fi.Name() != "file1") || (fi.Size() != 16) {
+		t.Fatalf("Unexpected info: %v %v\n", fi.Name(), fi.Size())
+	}
+
+	// Shadow the open file with a different one.
+	dir := t.TempDir()
+	err = os.MkdirAll(filepath.Join(dir, "dir1"), 0755)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "dir1", "file1"), []byte("new"), 0644)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(dir, "", false)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	fi, err = file.Stat()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	sys = fi.Sys().(*FileInfoSys)
+	if (fi.Size() != 16) || !fi.ModTime().IsZero() || !strings.HasSuffix(sys.RealDir, "zip1.aoi") {
+		t.Fatalf("Unexpected info for shadowed file: %v %v %+v\n", fi.Size(), fi.ModTime(), sys)
+	}
+
+	_, err = Stat("does/not/exist")
+	if err == nil {
+		t.Fatalf("Expected error.\n")
+	}
+}
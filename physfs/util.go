@@ -0,0 +1,138 @@
+package physfs
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// #include <stdlib.h>
+// #include "compat.h"
+//
+// #include "wrapcb.h"
+import "C"
+
+// Reads from cfile into buf until buf is full or the end of the file is
+// reached, with a single call into PhysicsFS. Returns the number of bytes read.
+// The caller must have locked the OS thread, and if the returned count is -1,
+// the error is left for lastError to pick up.
+func readFull(cfile *C.PHYSFS_File, buf []byte) int {
+	if len(buf) == 0 {
+		return 0
+	}
+
+	return int(C.PHYSFS_readBytes(cfile, unsafe.Pointer(&buf[0]), C.PHYSFS_uint64(len(buf))))
+}
+
+// Reads the whole of the named file from the search path and returns its
+// contents. Unlike reading the file with Open and File.Read, the buffer is
+// sized from the file's length and filled in a single read, where possible.
+// Returns an error, if any.
+func ReadFile(name string) ([]byte, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	cfile := C.PHYSFS_openRead(cname)
+	if cfile == nil {
+		return nil, lastError("open", name)
+	}
+	defer C.PHYSFS_close(cfile)
+
+	// The length is unknown for some archives, so start with something
+	// reasonable and grow from there.
+	size := int64(C.PHYSFS_fileLength(cfile))
+	if size < 0 {
+		size = 512
+	}
+
+	// One byte more than the length, so that reaching the end is noticed
+	// without another read if the file is as long as it claims.
+	data := make([]byte, 0, size+1)
+	for {
+		n := readFull(cfile, data[len(data):cap(data)])
+		if n == -1 {
+			err := lastError("read", name)
+			if IsDirectory(name) {
+				err = &PathError{Op: "read", Path: name, Code: ErrNotAFile}
+			}
+			return nil, err
+		}
+		data = data[:len(data)+n]
+
+		if len(data) < cap(data) {
+			return data, nil
+		}
+		data = append(data, 0)[:len(data)]
+	}
+}
+
+// Writes data to the named file, relative to the write dir, creating it if it
+// doesn't exist and truncating it if it does. The data is written with a single
+// write. Returns an error, if any.
+func WriteFile(name string, data []byte) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+
+	cfile := C.PHYSFS_openWrite(cname)
+	if cfile == nil {
+		return lastError("open", name)
+	}
+
+	if len(data) > 0 {
+		n := int(C.PHYSFS_writeBytes(cfile, unsafe.Pointer(&data[0]), C.PHYSFS_uint64(len(data))))
+		if n < len(data) {
+			err := lastError("write", name)
+			C.PHYSFS_close(cfile)
+			return err
+		}
+	}
+
+	if int(C.PHYSFS_close(cfile)) == 0 {
+		return lastError("close", name)
+	}
+
+	return nil
+}
+
+// Copies the named file src from the search path to the named file dst,
+// relative to the write dir, creating dst if it doesn't exist and truncating
+// it if it does. The data is copied by PhysicsFS without passing through Go.
+// Returns the number of bytes copied and an error, if any.
+func CopyFile(src, dst string) (int64, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	csrc := C.CString(src)
+	defer C.free(unsafe.Pointer(csrc))
+	cdst := C.CString(dst)
+	defer C.free(unsafe.Pointer(cdst))
+
+	in := C.PHYSFS_openRead(csrc)
+	if in == nil {
+		return 0, lastError("open", src)
+	}
+	defer C.PHYSFS_close(in)
+
+	out := C.PHYSFS_openWrite(cdst)
+	if out == nil {
+		return 0, lastError("open", dst)
+	}
+
+	n := int64(C.copyFile(in, out))
+	if n == -1 {
+		err := lastError("copy", src)
+		C.PHYSFS_close(out)
+		return 0, err
+	}
+
+	if int(C.PHYSFS_close(out)) == 0 {
+		return n, lastError("close", dst)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,152 @@
+package physfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupWriteDir(t testing.TB) string {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+
+	dir := t.TempDir()
+	err := SetWriteDir(dir)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount(dir, "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	return dir
+}
+
+func TestReadWriteFile(t *testing.T) {
+	dir := setupWriteDir(t)
+	defer Deinit()
+
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	err := WriteFile("data", data)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	read, err := ReadFile("data")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatalf("Read %v bytes, expected %v\n", len(read), len(data))
+	}
+
+	n, err := CopyFile("data", "copy")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("Copied %v bytes, expected %v\n", n, len(data))
+	}
+	copied, err := os.ReadFile(filepath.Join(dir, "copy"))
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("Copied data doesn't match\n")
+	}
+
+	err = WriteFile("empty", nil)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	read, err = ReadFile("empty")
+	if (err != nil) || (len(read) != 0) {
+		t.Fatalf("Unexpected result: %q, %v\n", read, err)
+	}
+
+	_, err = ReadFile("missing")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v\n", err)
+	}
+}
+
+func setupBenchmark(b *testing.B, size int) {
+	setupWriteDir(b)
+
+	err := WriteFile("bench", bytes.Repeat([]byte{'x'}, size))
+	if err != nil {
+		b.Fatalf("Error: %v\n", err)
+	}
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+}
+
+func benchmarkReadFile(b *testing.B, size int) {
+	setupBenchmark(b, size)
+	defer Deinit()
+
+	for i := 0; i < b.N; i++ {
+		_, err := ReadFile("bench")
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+	}
+}
+
+// Reads the file the way that was necessary before ReadFile.
+func benchmarkReadLoop(b *testing.B, size int) {
+	setupBenchmark(b, size)
+	defer Deinit()
+
+	for i := 0; i < b.N; i++ {
+		file, err := Open("bench")
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+		length, err := file.Length()
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+
+		data := make([]byte, length)
+		buf := make([]byte, 4096)
+		for n := 0; n < len(data); {
+			read, err := file.Read(buf)
+			n += copy(data[n:], buf[:read])
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatalf("Error: %v\n", err)
+			}
+		}
+
+		file.Close()
+	}
+}
+
+func BenchmarkReadFile4K(b *testing.B) { benchmarkReadFile(b, 4<<10) }
+func BenchmarkReadFile1M(b *testing.B) { benchmarkReadFile(b, 1<<20) }
+func BenchmarkReadLoop4K(b *testing.B) { benchmarkReadLoop(b, 4<<10) }
+func BenchmarkReadLoop1M(b *testing.B) { benchmarkReadLoop(b, 1<<20) }
+
+func BenchmarkCopyFile1M(b *testing.B) {
+	setupBenchmark(b, 1<<20)
+	defer Deinit()
+
+	for i := 0; i < b.N; i++ {
+		_, err := CopyFile("bench", "copy")
+		if err != nil {
+			b.Fatalf("Error: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package physfs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Returns the version in the form "major.minor.patch".
+func (v Version) String() string {
+	return fmt.Sprintf("%v.%v.%v", v.Major, v.Minor, v.Patch)
+}
+
+// Compares v to other, returning -1 if v is older, 0 if they're the same, and
+// 1 if v is newer.
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Major != other.Major:
+		return cmpUint8(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return cmpUint8(v.Minor, other.Minor)
+	default:
+		return cmpUint8(v.Patch, other.Patch)
+	}
+}
+
+func cmpUint8(a, b uint8) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Reports whether v is at least major.minor.
+func (v Version) atLeast(major, minor uint8) bool {
+	return v.Compare(Version{Major: major, Minor: minor}) >= 0
+}
+
+// Returned by Init when the linked version of PhysicsFS is older than the one
+// passed to RequireVersion. It matches ErrUnsupported with errors.Is.
+type VersionError struct {
+	Required Version
+	Linked   Version
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("PhysicsFS %v or newer is required, but %v is linked", e.Required, e.Linked)
+}
+
+func (e *VersionError) Unwrap() error {
+	return ErrUnsupported
+}
+
+var (
+	requiredVersionLock sync.Mutex
+	requiredVersion     Version
+)
+
+// Sets the oldest version of PhysicsFS that the application can run with.
+// Later calls to Init fail with a *VersionError if the linked library is older
+// than want, so that the problem is reported clearly at startup instead of as
+// ErrUnsupported errors later on.
+func RequireVersion(want Version) {
+	requiredVersionLock.Lock()
+	defer requiredVersionLock.Unlock()
+
+	requiredVersion = want
+}
+
+func checkVersion() error {
+	requiredVersionLock.Lock()
+	want := requiredVersion
+	requiredVersionLock.Unlock()
+
+	linked := *GetLinkedVersion()
+	if linked.Compare(want) < 0 {
+		return &VersionError{
+			Required: want,
+			Linked:   linked,
+		}
+	}
+
+	return nil
+}
+
+// Describes what the PhysicsFS that the bindings were built against and linked
+// with can do. Each feature needs support in both, so older headers limit what
+// is available even if the linked library is newer.
+type Features struct {
+	// The versions of PhysicsFS that the bindings were built against and are
+	// linked with, as returned by VERSION() and GetLinkedVersion().
+	Compiled Version
+	Linked   Version
+
+	// Whether Stat returns complete information. Without it, Stat is
+	// emulated, and only reports sizes, modification times and types.
+	Stat bool
+
+	// Whether MountMemory, MountReaderAt and MountFile can be used.
+	MountMemory bool
+
+	// Whether GetPrefDir can be used.
+	PrefDir bool
+
+	// Whether errors report precise error codes. Without them, codes are
+	// guessed from PhysicsFS's error messages, and are often ErrOtherError.
+	ErrorCodes bool
+
+	// Whether RegisterArchiver can be used.
+	Archivers bool
+
+	// Whether SetRoot can be used.
+	SetRoot bool
+
+	// Whether any of the archive types support symbolic links. Native
+	// directories can contain them regardless, if the platform has them.
+	Symlinks bool
+
+	// The supported archive types, as returned by SupportedArchiveTypes().
+	// This is empty if PhysicsFS hasn't been initialized.
+	ArchiveTypes []ArchiveInfo
+}
+
+// Returns a report of the features that are available, based on the version
+// of PhysicsFS that the bindings were built against and the one that they are
+// actually linked with. PhysicsFS should be initialized first so that the
+// supported archive types can be included.
+func Capabilities() Features {
+	compiled := *VERSION()
+	linked := *GetLinkedVersion()
+
+	// Features are only available if both versions have them.
+	v := compiled
+	if linked.Compare(v) < 0 {
+		v = linked
+	}
+
+	c := Features{
+		Compiled:     compiled,
+		Linked:       linked,
+		Stat:         v.atLeast(2, 1),
+		MountMemory:  v.atLeast(2, 1),
+		PrefDir:      v.atLeast(2, 1),
+		ErrorCodes:   v.atLeast(3, 0),
+		Archivers:    v.atLeast(3, 0),
+		SetRoot:      v.atLeast(3, 1),
+		ArchiveTypes: SupportedArchiveTypes(),
+	}
+	for _, ai := range c.ArchiveTypes {
+		c.Symlinks = c.Symlinks || ai.SupportsSymlinks
+	}
+
+	return c
+}
+
+// Returns information about the archive type with the extension ext, which is
+// compared without regard to case, and whether or not it's supported.
+func (c Features) ArchiveType(ext string) (ArchiveInfo, bool) {
+	ext = strings.TrimPrefix(ext, ".")
+	for _, ai := range c.ArchiveTypes {
+		if strings.EqualFold(ai.Extension, ext) {
+			return ai, true
+		}
+	}
+
+	return ArchiveInfo{}, false
+}
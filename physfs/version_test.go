@@ -0,0 +1,66 @@
+package physfs
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	v := Version{Major: 3, Minor: 0, Patch: 2}
+	if v.String() != "3.0.2" {
+		t.Fatalf("Unexpected string: %q\n", v.String())
+	}
+
+	tests := []struct {
+		other Version
+		cmp   int
+	}{
+		{Version{3, 0, 2}, 0},
+		{Version{3, 1, 0}, -1},
+		{Version{2, 9, 9}, 1},
+		{Version{3, 0, 10}, -1},
+	}
+	for _, test := range tests {
+		if cmp := v.Compare(test.other); cmp != test.cmp {
+			t.Errorf("%v.Compare(%v) = %v, expected %v\n", v, test.other, cmp, test.cmp)
+		}
+	}
+}
+
+func TestRequireVersion(t *testing.T) {
+	if IsInit() {
+		Deinit()
+	}
+
+	RequireVersion(Version{Major: 255})
+	err := Init()
+	RequireVersion(Version{})
+
+	var verr *VersionError
+	if !errors.As(err, &verr) || !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Expected *VersionError, got %v\n", err)
+	}
+	if verr.Linked != *GetLinkedVersion() {
+		t.Fatalf("Unexpected linked version: %v\n", verr.Linked)
+	}
+	if IsInit() {
+		t.Fatalf("Initialized despite old version.\n")
+	}
+
+	err = Init()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer Deinit()
+
+	features := Capabilities()
+	if features.Linked != *GetLinkedVersion() {
+		t.Fatalf("Unexpected linked version: %v\n", features.Linked)
+	}
+	if features.SetRoot && !features.Stat {
+		t.Fatalf("Inconsistent features: %+v\n", features)
+	}
+	if _, ok := features.ArchiveType(".zip"); !ok {
+		t.Fatalf("ZIP support not reported: %v\n", features.ArchiveTypes)
+	}
+}
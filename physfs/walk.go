@@ -0,0 +1,263 @@
+package physfs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Returns information about the native directories behind name, following
+// symbolic links, for each native directory in the search path that provides
+// it.
+func nativeDirs(name string) []os.FileInfo {
+	sp, err := GetSearchPath()
+	if err != nil {
+		return nil
+	}
+
+	name = strings.Trim(name, "/")
+
+	var dirs []os.FileInfo
+	for _, dir := range sp {
+		mp, err := GetMountPoint(dir)
+		if err != nil {
+			continue
+		}
+		mp = strings.Trim(mp, "/")
+
+		rel, ok := strings.CutPrefix(name, mp)
+		if !ok || ((mp != "") && (rel != "") && (rel[0] != '/')) {
+			continue
+		}
+
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(rel)))
+		if (err != nil) || !info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, info)
+	}
+
+	return dirs
+}
+
+// Decides whether the walk should descend into the entry name, which is d.
+// Symbolic links are followed if they are permitted and lead to native
+// directories that aren't among ancestors, the native directories that are
+// already being walked. Returns whether to descend and the ancestors for the
+// entry's own children.
+func descend(name string, d fs.DirEntry, ancestors []os.FileInfo) (bool, []os.FileInfo) {
+	if !SymbolicLinksPermitted() {
+		return d.IsDir(), nil
+	}
+
+	isLink := d.Type()&fs.ModeSymlink != 0
+	if !d.IsDir() && !isLink {
+		return false, nil
+	}
+
+	dirs := nativeDirs(name)
+	for _, dir := range dirs {
+		for _, a := range ancestors {
+			if os.SameFile(a, dir) {
+				return false, nil
+			}
+		}
+	}
+
+	if isLink && (len(dirs) == 0) {
+		// Not a link to a directory.
+		return false, nil
+	}
+
+	return true, append(ancestors[:len(ancestors):len(ancestors)], dirs...)
+}
+
+// Walks the file tree rooted at root in the search path, calling fn for each
+// file or directory in the tree, including root. Everything in the search path
+// is walked as a single tree, so a directory provided by several archives or
+// directories is visited once, with the entries from all of them. Otherwise,
+// WalkDir behaves like fs.WalkDir, visiting entries in lexical order and
+// handling fs.SkipDir and fs.SkipAll in the same way.
+//
+// Symbolic links are reported to fn but not followed, unless
+// PermitSymbolicLinks(true) has been called, in which case links to directories
+// in native directories in the search path are walked as directories. A link
+// to a directory that is already being walked is not followed again, to avoid
+// cycles.
+func WalkDir(root string, fn fs.WalkDirFunc) error {
+	info, err := Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(root, fs.FileInfoToDirEntry(info), fn, nil)
+	}
+
+	if (err == fs.SkipDir) || (err == fs.SkipAll) {
+		return nil
+	}
+
+	return err
+}
+
+func walkDir(name string, d fs.DirEntry, fn fs.WalkDirFunc, ancestors []os.FileInfo) error {
+	isDir, ancestors := descend(name, d, ancestors)
+
+	if err := fn(name, d, nil); (err != nil) || !isDir {
+		if (err == fs.SkipDir) && isDir {
+			// Successfully skipped directory.
+			err = nil
+		}
+		return err
+	}
+
+	entries, err := ReadDir(name)
+	if err != nil {
+		// Second call, to report the ReadDir error.
+		err = fn(name, d, err)
+		if err != nil {
+			if err == fs.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		err := walkDir(path.Join(name, entry.Name()), entry, fn, ancestors)
+		if err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Returns the names of all files and directories in the search path that match
+// pattern, in lexical order. The syntax is that of path.Match, applied to each
+// "/" separated part of the pattern, with the addition that a part that is
+// exactly "**" matches any number of directories, including none. For example,
+// "maps/**/*.map" matches "maps/a.map" and "maps/desert/night/b.map". Like
+// WalkDir, Glob works on the merged contents of the search path and guards
+// against symbolic link cycles. The only possible error is
+// path.ErrBadPattern.
+func Glob(pattern string) ([]string, error) {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	recursive := false
+	for _, part := range parts {
+		if part == "**" {
+			recursive = true
+			continue
+		}
+
+		if _, err := path.Match(part, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	if recursive {
+		matches = globRecursive(parts)
+	} else {
+		globParts("", parts, &matches)
+	}
+
+	// The root itself is never a match, just as with fs.Glob.
+	matches = slices.DeleteFunc(matches, func(m string) bool {
+		return m == ""
+	})
+
+	slices.Sort(matches)
+	return slices.Compact(matches), nil
+}
+
+// Appends the matches for parts, none of which are "**", in dir to matches.
+func globParts(dir string, parts []string, matches *[]string) {
+	if len(parts) == 0 {
+		*matches = append(*matches, dir)
+		return
+	}
+
+	if !hasMeta(parts[0]) {
+		name := path.Join(dir, parts[0])
+		if Exists(name) {
+			globParts(name, parts[1:], matches)
+		}
+		return
+	}
+
+	names, err := readDirNames(dir)
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		if ok, _ := path.Match(parts[0], name); ok {
+			globParts(path.Join(dir, name), parts[1:], matches)
+		}
+	}
+}
+
+// Returns the matches for parts, which contain at least one "**", by walking
+// everything below the part of the pattern before the first wildcard.
+func globRecursive(parts []string) []string {
+	prefix := 0
+	for (prefix < len(parts)) && (parts[prefix] != "**") && !hasMeta(parts[prefix]) {
+		prefix++
+	}
+	root := path.Join(parts[:prefix]...)
+
+	var matches []string
+	WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		var relParts []string
+		if rel != "" {
+			relParts = strings.Split(rel, "/")
+		}
+		if matchParts(parts[prefix:], relParts) {
+			matches = append(matches, name)
+		}
+
+		return nil
+	})
+
+	return matches
+}
+
+// Reports whether the parts of a name match the parts of a pattern, either of
+// which may be "**".
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,177 @@
+package physfs
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWalkDir(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	err := Mount("../test/zip1.aoi", "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = Mount("../test/a.zip", "a", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	var names []string
+	err = WalkDir("", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "a" {
+			return fs.SkipDir
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(names, []string{"", "dir1", "dir1/file1"}) {
+		t.Fatalf("Unexpected names: %v\n", names)
+	}
+
+	count := 0
+	err = WalkDir("", func(name string, d fs.DirEntry, err error) error {
+		count++
+		return fs.SkipAll
+	})
+	if (err != nil) || (count != 1) {
+		t.Fatalf("SkipAll didn't stop the walk: %v, %v\n", count, err)
+	}
+
+	matches, err := Glob("**/file1")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(matches, []string{"dir1/file1"}) {
+		t.Fatalf("Unexpected matches: %v\n", matches)
+	}
+
+	matches, err = Glob("a/*.html")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(matches, []string{"a/index.html"}) {
+		t.Fatalf("Unexpected matches: %v\n", matches)
+	}
+
+	matches, err = Glob("")
+	if (err != nil) || (len(matches) != 0) {
+		t.Fatalf("Unexpected matches: %v, %v\n", matches, err)
+	}
+	matches, err = Glob("**")
+	if (err != nil) || slices.Contains(matches, "") || !slices.Contains(matches, "dir1/file1") {
+		t.Fatalf("Unexpected matches: %v, %v\n", matches, err)
+	}
+
+	_, err = Glob("a/[")
+	if err != path.ErrBadPattern {
+		t.Fatalf("Expected bad pattern, got %v\n", err)
+	}
+}
+
+func TestWalkDirSymlinkCycle(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = os.Symlink("..", filepath.Join(dir, "sub", "loop"))
+	if err != nil {
+		t.Skipf("Can't create symlink: %v\n", err)
+	}
+
+	err = Mount(dir, "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	PermitSymbolicLinks(true)
+
+	var names []string
+	err = WalkDir("", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(names, []string{"", "sub", "sub/loop"}) {
+		t.Fatalf("Unexpected names: %v\n", names)
+	}
+}
+
+func TestWalkDirSymlinkSibling(t *testing.T) {
+	if !IsInit() {
+		err := Init()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	defer Deinit()
+
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "real"), 0755)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "real", "file"), []byte("file"), 0644)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	err = os.Symlink("real", filepath.Join(dir, "link"))
+	if err != nil {
+		t.Skipf("Can't create symlink: %v\n", err)
+	}
+
+	err = Mount(dir, "", true)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	PermitSymbolicLinks(true)
+
+	var names []string
+	err = WalkDir("", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if !slices.Equal(names, []string{"", "link", "link/file", "real", "real/file"}) {
+		t.Fatalf("Unexpected names: %v\n", names)
+	}
+
+	matches, err := Glob("link/*")
+	if (err != nil) || !slices.Equal(matches, []string{"link/file"}) {
+		t.Fatalf("Unexpected matches: %v, %v\n", matches, err)
+	}
+}
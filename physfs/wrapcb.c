@@ -1,18 +1,230 @@
-#include <physfs.h>
+#include <stdlib.h>
+#include "compat.h"
 
 #include "_cgo_export.h"
 
-void getCdRomDirsCallback(void *d)
+static void stringCallback(void *d, const char *str)
 {
-	PHYSFS_getCdRomDirsCallback((PHYSFS_StringCallback)&wrapStringCallback, d);
+	wrapStringCallback((uintptr_t)d, (char *)str);
 }
 
-void getSearchPathCallback(void *d)
+void getCdRomDirsCallback(uintptr_t d)
 {
-	PHYSFS_getSearchPathCallback((PHYSFS_StringCallback)&wrapStringCallback, d);
+	PHYSFS_getCdRomDirsCallback(&stringCallback, (void *)d);
 }
 
-void enumerateFilesCallback(char *dir, void *d)
+void getSearchPathCallback(uintptr_t d)
 {
-	PHYSFS_enumerateFilesCallback(dir, (PHYSFS_EnumFilesCallback)&wrapEnumFilesCallback, d);
+	PHYSFS_getSearchPathCallback(&stringCallback, (void *)d);
+}
+
+static PHYSFS_EnumerateCallbackResult enumFilesCallback(void *d, const char *origdir, const char *fname)
+{
+	return wrapEnumFilesCallback((uintptr_t)d, (char *)origdir, (char *)fname);
+}
+
+void enumerateFilesCallback(char *dir, uintptr_t d)
+{
+	PHYSFS_enumerate(dir, &enumFilesCallback, (void *)d);
+}
+
+int mountMemory(void *buf, PHYSFS_uint64 len, char *name, char *mp, int app)
+{
+	return PHYSFS_mountMemory(buf, len, &releaseMemoryMount, name, mp, app);
+}
+
+static PHYSFS_sint64 readerAtIoWrite(PHYSFS_Io *io, const void *buf, PHYSFS_uint64 len)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_READ_ONLY);
+	return -1;
+}
+
+PHYSFS_Io *newReaderAtIo(uintptr_t h)
+{
+	PHYSFS_Io *io = malloc(sizeof(PHYSFS_Io));
+	if (io == NULL)
+	{
+		PHYSFS_setErrorCode(PHYSFS_ERR_OUT_OF_MEMORY);
+		return NULL;
+	}
+
+	io->version = 0;
+	io->opaque = (void *)h;
+	io->read = &readerAtIoRead;
+	io->write = &readerAtIoWrite;
+	io->seek = &readerAtIoSeek;
+	io->tell = &readerAtIoTell;
+	io->length = &readerAtIoLength;
+	io->duplicate = &readerAtIoDuplicate;
+	io->flush = &readerAtIoFlush;
+	io->destroy = &readerAtIoDestroy;
+
+	return io;
+}
+
+#if PHYSFS_AT_LEAST(3, 0)
+
+static void *openArchive(int slot, PHYSFS_Io *io, const char *name, int forWrite, int *claimed)
+{
+	if (forWrite)
+	{
+		PHYSFS_setErrorCode(PHYSFS_ERR_READ_ONLY);
+		return NULL;
+	}
+
+	return (void *)archiverOpenArchive(slot, io, (char *)name, claimed);
+}
+
+#define OPEN_ARCHIVE_SLOT(n) \
+	static void *openArchive##n(PHYSFS_Io *io, const char *name, int forWrite, int *claimed) \
+	{ \
+		return openArchive(n, io, name, forWrite, claimed); \
+	}
+
+OPEN_ARCHIVE_SLOT(0)
+OPEN_ARCHIVE_SLOT(1)
+OPEN_ARCHIVE_SLOT(2)
+OPEN_ARCHIVE_SLOT(3)
+OPEN_ARCHIVE_SLOT(4)
+OPEN_ARCHIVE_SLOT(5)
+OPEN_ARCHIVE_SLOT(6)
+OPEN_ARCHIVE_SLOT(7)
+OPEN_ARCHIVE_SLOT(8)
+OPEN_ARCHIVE_SLOT(9)
+OPEN_ARCHIVE_SLOT(10)
+OPEN_ARCHIVE_SLOT(11)
+OPEN_ARCHIVE_SLOT(12)
+OPEN_ARCHIVE_SLOT(13)
+OPEN_ARCHIVE_SLOT(14)
+OPEN_ARCHIVE_SLOT(15)
+
+static void *(*openArchiveSlots[ARCHIVER_SLOTS])(PHYSFS_Io *, const char *, int, int *) = {
+	&openArchive0, &openArchive1, &openArchive2, &openArchive3,
+	&openArchive4, &openArchive5, &openArchive6, &openArchive7,
+	&openArchive8, &openArchive9, &openArchive10, &openArchive11,
+	&openArchive12, &openArchive13, &openArchive14, &openArchive15,
+};
+
+static PHYSFS_EnumerateCallbackResult enumerateArchive(void *opaque, const char *dir, PHYSFS_EnumerateCallback cb, const char *origdir, void *data)
+{
+	return archiverEnumerate((uintptr_t)opaque, (char *)dir, cb, (char *)origdir, data);
+}
+
+static PHYSFS_Io *openReadArchive(void *opaque, const char *name)
+{
+	return archiverOpenRead((uintptr_t)opaque, (char *)name);
+}
+
+static PHYSFS_Io *openWriteArchive(void *opaque, const char *name)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_READ_ONLY);
+	return NULL;
+}
+
+static int modifyArchive(void *opaque, const char *name)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_READ_ONLY);
+	return 0;
+}
+
+static int statArchive(void *opaque, const char *name, PHYSFS_Stat *stat)
+{
+	return archiverStat((uintptr_t)opaque, (char *)name, stat);
+}
+
+static void closeArchive(void *opaque)
+{
+	archiverClose((uintptr_t)opaque);
+}
+
+int registerArchiver(int slot, char *ext, char *desc, char *author, char *url, int symlinks)
+{
+	PHYSFS_Archiver archiver;
+
+	archiver.version = 0;
+	archiver.info.extension = ext;
+	archiver.info.description = desc;
+	archiver.info.author = author;
+	archiver.info.url = url;
+	archiver.info.supportsSymlinks = symlinks;
+	archiver.openArchive = openArchiveSlots[slot];
+	archiver.enumerate = &enumerateArchive;
+	archiver.openRead = &openReadArchive;
+	archiver.openWrite = &openWriteArchive;
+	archiver.openAppend = &openWriteArchive;
+	archiver.remove = &modifyArchive;
+	archiver.mkdir = &modifyArchive;
+	archiver.stat = &statArchive;
+	archiver.closeArchive = &closeArchive;
+
+	return PHYSFS_registerArchiver(&archiver);
+}
+
+#else
+
+int registerArchiver(int slot, char *ext, char *desc, char *author, char *url, int symlinks)
+{
+	PHYSFS_setErrorCode(PHYSFS_ERR_UNSUPPORTED);
+	return 0;
+}
+
+#endif
+
+PHYSFS_EnumerateCallbackResult callEnumerateCallback(PHYSFS_EnumerateCallback cb, void *data, char *origdir, char *fname)
+{
+	PHYSFS_EnumerateCallbackResult r = cb(data, origdir, fname);
+	if (r == PHYSFS_ENUM_ERROR)
+		PHYSFS_setErrorCode(PHYSFS_ERR_APP_CALLBACK);
+
+	return r;
+}
+
+PHYSFS_sint64 ioReadAt(PHYSFS_Io *io, void *buf, PHYSFS_uint64 len, PHYSFS_uint64 off)
+{
+	if (!io->seek(io, off))
+		return -1;
+
+	return io->read(io, buf, len);
+}
+
+PHYSFS_sint64 ioLength(PHYSFS_Io *io)
+{
+	return io->length(io);
+}
+
+void ioDestroy(PHYSFS_Io *io)
+{
+	io->destroy(io);
+}
+
+static PHYSFS_EnumerateCallbackResult enumerateCallback(void *d, const char *origdir, const char *fname)
+{
+	return wrapEnumerateCallback((uintptr_t)d, (char *)origdir, (char *)fname);
+}
+
+int enumerate(char *dir, uintptr_t d)
+{
+	return PHYSFS_enumerate(dir, &enumerateCallback, (void *)d);
+}
+
+PHYSFS_sint64 copyFile(PHYSFS_File *src, PHYSFS_File *dst)
+{
+	char buf[64 * 1024];
+	PHYSFS_sint64 total = 0;
+
+	for (;;)
+	{
+		PHYSFS_sint64 n = PHYSFS_readBytes(src, buf, sizeof(buf));
+		if (n < 0)
+			return -1;
+		if (n == 0)
+			return total;
+
+		if (PHYSFS_writeBytes(dst, buf, n) < n)
+			return -1;
+		total += n;
+
+		if (n < (PHYSFS_sint64)sizeof(buf) && PHYSFS_eof(src))
+			return total;
+	}
 }
@@ -1,3 +1,17 @@
-void getCdRomDirsCallback(void *);
-void getSearchPathCallback(void *);
-void enumerateFilesCallback(char *, void *);
+#include <stdint.h>
+#include "compat.h"
+
+#define ARCHIVER_SLOTS 16
+
+void getCdRomDirsCallback(uintptr_t);
+void getSearchPathCallback(uintptr_t);
+void enumerateFilesCallback(char *, uintptr_t);
+int enumerate(char *, uintptr_t);
+int mountMemory(void *, PHYSFS_uint64, char *, char *, int);
+PHYSFS_Io *newReaderAtIo(uintptr_t);
+PHYSFS_EnumerateCallbackResult callEnumerateCallback(PHYSFS_EnumerateCallback, void *, char *, char *);
+int registerArchiver(int, char *, char *, char *, char *, int);
+PHYSFS_sint64 ioReadAt(PHYSFS_Io *, void *, PHYSFS_uint64, PHYSFS_uint64);
+PHYSFS_sint64 ioLength(PHYSFS_Io *);
+void ioDestroy(PHYSFS_Io *);
+PHYSFS_sint64 copyFile(PHYSFS_File *, PHYSFS_File *);
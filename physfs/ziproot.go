@@ -0,0 +1,278 @@
+package physfs
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Opens the native file name as a ZIP archive. Returns nil, without an error,
+// if it isn't one.
+func openZip(name string) (*zip.Reader, *os.File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, nil, nil
+	}
+
+	return zr, file, nil
+}
+
+// Returns the name of the only top-level entry in zr, if it's a directory, or
+// "" otherwise.
+func zipWrapperDir(zr *zip.Reader) string {
+	var top string
+	for _, f := range zr.File {
+		name := strings.TrimPrefix(f.Name, "/")
+		first, _, isDir := strings.Cut(name, "/")
+		switch {
+		case first == "":
+			continue
+		case !isDir:
+			// A file at the top level.
+			return ""
+		case (top != "") && (first != top):
+			return ""
+		}
+		top = first
+	}
+
+	return top
+}
+
+// A part of a splicedZip, which either holds its bytes or refers to a range of
+// another file.
+type splicePart struct {
+	off  int64
+	size int64
+
+	data []byte
+
+	src    io.ReaderAt
+	srcOff int64
+}
+
+// A ZIP archive put together from the headers of a new archive, which are
+// held in memory, and the contents of the entries of an existing one, which
+// are read from it as needed. This allows the entries of an archive to be
+// renamed without copying their contents.
+type splicedZip struct {
+	parts []splicePart
+	size  int64
+
+	// While set, the bytes written are the raw contents of an entry, which
+	// are recorded as a reference to src rather than kept.
+	src    io.ReaderAt
+	srcOff int64
+}
+
+func (z *splicedZip) Write(p []byte) (int, error) {
+	n := int64(len(p))
+
+	last := len(z.parts) - 1
+	switch {
+	case z.src != nil:
+		if (last >= 0) && (z.parts[last].src == z.src) && (z.parts[last].srcOff+z.parts[last].size == z.srcOff) {
+			z.parts[last].size += n
+		} else {
+			z.parts = append(z.parts, splicePart{off: z.size, size: n, src: z.src, srcOff: z.srcOff})
+		}
+		z.srcOff += n
+	case (last >= 0) && (z.parts[last].src == nil):
+		z.parts[last].data = append(z.parts[last].data, p...)
+		z.parts[last].size += n
+	default:
+		z.parts = append(z.parts, splicePart{off: z.size, size: n, data: append([]byte(nil), p...)})
+	}
+
+	z.size += n
+	return len(p), nil
+}
+
+func (z *splicedZip) ReadAt(buf []byte, off int64) (n int, err error) {
+	i := sort.Search(len(z.parts), func(i int) bool {
+		return z.parts[i].off+z.parts[i].size > off
+	})
+
+	for (n < len(buf)) && (i < len(z.parts)) {
+		p := z.parts[i]
+		start := off + int64(n) - p.off
+		want := min(int64(len(buf)-n), p.size-start)
+
+		var read int
+		if p.src == nil {
+			read = copy(buf[n:n+int(want)], p.data[start:])
+		} else {
+			read, err = p.src.ReadAt(buf[n:n+int(want)], p.srcOff+start)
+			if (err != nil) && ((err != io.EOF) || (int64(read) < want)) {
+				return n + read, err
+			}
+		}
+
+		n += read
+		i++
+	}
+
+	if n < len(buf) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// An io.Reader of zeros, used to account for the contents of entries that are
+// spliced in rather than copied.
+type zeroReader struct{}
+
+func (zeroReader) Read(buf []byte) (int, error) {
+	clear(buf)
+	return len(buf), nil
+}
+
+// Returns a ZIP archive containing only the entries of zr, which is read from
+// src, that are inside of the directory root, with root removed from their
+// names.
+func spliceZipRoot(zr *zip.Reader, src io.ReaderAt, root string) (*splicedZip, error) {
+	z := new(splicedZip)
+	zw := zip.NewWriter(z)
+
+	prefix := root + "/"
+	for _, f := range zr.File {
+		name, ok := strings.CutPrefix(strings.TrimPrefix(f.Name, "/"), prefix)
+		if !ok || (name == "") {
+			continue
+		}
+
+		off, err := f.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+
+		fh := f.FileHeader
+		fh.Name = name
+		fh.Extra = nil
+
+		// The sizes are known, so they go in the local header instead of a
+		// data descriptor after the contents.
+		fh.Flags &^= 0x8
+
+		fw, err := zw.CreateRaw(&fh)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		// zip.Writer buffers what it writes, so everything has to be flushed
+		// through to z when switching between headers and contents.
+		if err := zw.Flush(); err != nil {
+			return nil, err
+		}
+		z.src, z.srcOff = src, off
+		_, err = io.CopyN(fw, zeroReader{}, int64(f.CompressedSize64))
+		if err == nil {
+			err = zw.Flush()
+		}
+		z.src = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return z, nil
+}
+
+// A native file that is only kept open while PhysicsFS is reading from it.
+type sharedFile struct {
+	name string
+
+	lock sync.Mutex
+	file *os.File
+	refs int
+}
+
+func (f *sharedFile) acquire() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		file, err := os.Open(f.name)
+		if err != nil {
+			return err
+		}
+		f.file = file
+	}
+	f.refs++
+
+	return nil
+}
+
+func (f *sharedFile) release() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.refs--
+	if f.refs == 0 {
+		f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *sharedFile) ReadAt(buf []byte, off int64) (int, error) {
+	f.lock.Lock()
+	file := f.file
+	f.lock.Unlock()
+
+	if file == nil {
+		return 0, os.ErrClosed
+	}
+
+	return file.ReadAt(buf, off)
+}
+
+// The archive mounted by mountZipRoot, which keeps the original open while
+// PhysicsFS is reading from it.
+type zipRoot struct {
+	*splicedZip
+	src *sharedFile
+}
+
+func (z zipRoot) acquire() error { return z.src.acquire() }
+func (z zipRoot) release()       { z.src.release() }
+
+// Mounts the ZIP archive dir, which has been opened as zr, with root as its
+// root, as part of b, for versions of PhysicsFS without SetRoot. A copy of the
+// archive's directory with root removed from the names is mounted in its
+// place, under the same name, which reads the contents of the entries from
+// the original. The original is opened again for as long as PhysicsFS has any
+// streams on the copy, which it duplicates as it pleases, and closed once it
+// destroys the last of them, including when the copy is mounted again by
+// MoveMount and friends.
+func mountZipRoot(b *batch, dir string, zr *zip.Reader, root, mp string, app bool) error {
+	src := &sharedFile{name: dir}
+	z, err := spliceZipRoot(zr, src, root)
+	if err != nil {
+		return err
+	}
+
+	return mountReaderAt(b, zipRoot{z, src}, z.size, dir, mp, app)
+}
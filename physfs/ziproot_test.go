@@ -0,0 +1,111 @@
+package physfs
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpliceZipRoot(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"MyMod/":             "",
+		"MyMod/readme.txt":   "Hello.",
+		"MyMod/maps/a.map":   string(bytes.Repeat([]byte("map data "), 1000)),
+		"MyMod/maps/b.map":   "",
+		"MyModExtra/ignored": "ignored",
+	}
+	for _, name := range []string{"MyMod/", "MyMod/readme.txt", "MyMod/maps/a.map", "MyMod/maps/b.map"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		io.WriteString(w, files[name])
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	src := bytes.NewReader(buf.Bytes())
+	zr, err := zip.NewReader(src, src.Size())
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if root := zipWrapperDir(zr); root != "MyMod" {
+		t.Fatalf("Expected wrapper MyMod, got %q\n", root)
+	}
+
+	z, err := spliceZipRoot(zr, src, "MyMod")
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	spliced, err := zip.NewReader(z, z.size)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	if zipWrapperDir(spliced) != "" {
+		t.Fatalf("Spliced archive still has a wrapper directory.\n")
+	}
+
+	if len(spliced.File) != 3 {
+		t.Fatalf("Expected 3 entries, got %v\n", len(spliced.File))
+	}
+	for _, f := range spliced.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+		if string(data) != files["MyMod/"+f.Name] {
+			t.Fatalf("Unexpected contents of %v: %q\n", f.Name, data)
+		}
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "data")
+	err := os.WriteFile(name, []byte("data"), 0644)
+	if err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+
+	f := &sharedFile{name: name}
+	buf := make([]byte, 4)
+	if _, err := f.ReadAt(buf, 0); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Expected ErrClosed before acquire, got %v\n", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := f.acquire(); err != nil {
+			t.Fatalf("Error: %v\n", err)
+		}
+	}
+	f.release()
+	if n, err := f.ReadAt(buf, 0); (err != nil) || (string(buf[:n]) != "data") {
+		t.Fatalf("Unexpected read: %q, %v\n", buf[:n], err)
+	}
+
+	f.release()
+	if _, err := f.ReadAt(buf, 0); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Expected ErrClosed after release, got %v\n", err)
+	}
+
+	// Opened again when it's needed again.
+	if err := f.acquire(); err != nil {
+		t.Fatalf("Error: %v\n", err)
+	}
+	defer f.release()
+	if n, err := f.ReadAt(buf, 0); (err != nil) || (string(buf[:n]) != "data") {
+		t.Fatalf("Unexpected read: %q, %v\n", buf[:n], err)
+	}
+}